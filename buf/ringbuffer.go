@@ -1,47 +1,201 @@
 package buf
 
 import (
-	"container/ring"
 	"sync"
+	"time"
 )
 
-// RingBuffer represents synchronized ring implementation
-type RingBuffer struct {
-	r *ring.Ring
-	m *sync.RWMutex
+// RingBuffer represents synchronized ring of the latest items.
+// Optionally items expire after TTL and items with the same key replace each other
+type RingBuffer[T any] struct {
+	m     sync.RWMutex
+	items []entry[T]
+	// start is an index of the oldest item
+	start int
+	count int
+
+	ttl time.Duration
+	key func(T) string
+	now func() time.Time
+}
+
+type entry[T any] struct {
+	value T
+	added time.Time
+}
+
+// Option configures RingBuffer
+type Option[T any] func(*RingBuffer[T])
+
+// WithTTL makes items expire once they stay in the buffer longer than ttl
+func WithTTL[T any](ttl time.Duration) Option[T] {
+	return func(buf *RingBuffer[T]) {
+		buf.ttl = ttl
+	}
 }
 
-// New creates new RingBuffer
-func New(size int) *RingBuffer {
-	return &RingBuffer{
-		ring.New(size),
-		&sync.RWMutex{},
+// WithKey deduplicates items by key. Added item replaces the previous one with the same key
+func WithKey[T any](key func(T) string) Option[T] {
+	return func(buf *RingBuffer[T]) {
+		buf.key = key
 	}
 }
 
-// Add adds item to ring
-func (buf *RingBuffer) Add(v interface{}) {
+// WithClock sets source of the current time used to expire items
+func WithClock[T any](now func() time.Time) Option[T] {
+	return func(buf *RingBuffer[T]) {
+		buf.now = now
+	}
+}
+
+// New creates new RingBuffer. Negative size is treated as zero
+func New[T any](size int, opts ...Option[T]) *RingBuffer[T] {
+	buf := &RingBuffer[T]{
+		items: make([]entry[T], max(size, 0)),
+		now:   time.Now,
+	}
+	for _, opt := range opts {
+		opt(buf)
+	}
+	return buf
+}
+
+// Add adds item to ring, the oldest item is evicted once the ring is full
+func (buf *RingBuffer[T]) Add(v T) {
 	buf.m.Lock()
 	defer buf.m.Unlock()
-	buf.r.Value = v
-	buf.r = buf.r.Next()
+
+	if buf.key != nil {
+		k := buf.key(v)
+		for i := 0; i < buf.count; i++ {
+			if buf.key(buf.at(i).value) == k {
+				buf.remove(i)
+				break
+			}
+		}
+	}
+	if len(buf.items) == 0 {
+		return
+	}
+
+	e := entry[T]{value: v, added: buf.now()}
+	if buf.count == len(buf.items) {
+		buf.items[buf.start] = e
+		buf.start = (buf.start + 1) % len(buf.items)
+		return
+	}
+	buf.items[(buf.start+buf.count)%len(buf.items)] = e
+	buf.count++
 }
 
-// Last returned last item added to the ring
-func (buf *RingBuffer) Last() interface{} {
+// Touch renews TTL of the item with the key. It reports whether the item is in the ring and not expired.
+// Items are looked up only when the ring deduplicates them by key
+func (buf *RingBuffer[T]) Touch(key string) bool {
 	buf.m.Lock()
 	defer buf.m.Unlock()
-	return buf.r.Prev().Value
+
+	if buf.key == nil {
+		return false
+	}
+	now := buf.now()
+	for i := 0; i < buf.count; i++ {
+		e := &buf.items[(buf.start+i)%len(buf.items)]
+		if buf.key(e.value) == key && !buf.expired(*e, now) {
+			e.added = now
+			return true
+		}
+	}
+	return false
 }
 
-// Do executes provided callback on all non-nil items of a ring
-func (buf *RingBuffer) Do(f func(interface{})) {
+// Last returns last item added to the ring
+func (buf *RingBuffer[T]) Last() (T, bool) {
 	buf.m.RLock()
+	defer buf.m.RUnlock()
 
+	now := buf.now()
+	for i := buf.count - 1; i >= 0; i-- {
+		if e := buf.at(i); !buf.expired(e, now) {
+			return e.value, true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+// Do executes provided callback on all items of a ring from the oldest to the newest
+func (buf *RingBuffer[T]) Do(f func(T)) {
+	buf.m.RLock()
 	defer buf.m.RUnlock()
-	buf.r.Do(func(v interface{}) {
-		if nil != v {
-			f(v)
+
+	now := buf.now()
+	for i := 0; i < buf.count; i++ {
+		if e := buf.at(i); !buf.expired(e, now) {
+			f(e.value)
+		}
+	}
+}
+
+// Snapshot returns copy of items from the newest to the oldest
+func (buf *RingBuffer[T]) Snapshot() []T {
+	buf.m.RLock()
+	defer buf.m.RUnlock()
+
+	now := buf.now()
+	items := make([]T, 0, buf.count)
+	for i := buf.count - 1; i >= 0; i-- {
+		if e := buf.at(i); !buf.expired(e, now) {
+			items = append(items, e.value)
+		}
+	}
+	return items
+}
+
+// Len returns number of items which are not expired
+func (buf *RingBuffer[T]) Len() int {
+	buf.m.RLock()
+	defer buf.m.RUnlock()
+
+	now := buf.now()
+	n := 0
+	for i := 0; i < buf.count; i++ {
+		if !buf.expired(buf.at(i), now) {
+			n++
 		}
-	})
+	}
+	return n
+}
+
+// Resize changes capacity of the ring keeping the newest items. Negative size is treated as zero
+func (buf *RingBuffer[T]) Resize(size int) {
+	buf.m.Lock()
+	defer buf.m.Unlock()
+
+	size = max(size, 0)
+	count := min(buf.count, size)
+	items := make([]entry[T], size)
+	for i := 0; i < count; i++ {
+		items[i] = buf.at(buf.count - count + i)
+	}
+	buf.items = items
+	buf.start = 0
+	buf.count = count
+}
+
+// at returns i-th item counting from the oldest one. Must be called under lock
+func (buf *RingBuffer[T]) at(i int) entry[T] {
+	return buf.items[(buf.start+i)%len(buf.items)]
+}
+
+// remove removes i-th item counting from the oldest one. Must be called under write lock
+func (buf *RingBuffer[T]) remove(i int) {
+	for ; i < buf.count-1; i++ {
+		buf.items[(buf.start+i)%len(buf.items)] = buf.at(i + 1)
+	}
+	buf.items[(buf.start+buf.count-1)%len(buf.items)] = entry[T]{}
+	buf.count--
+}
+
+func (buf *RingBuffer[T]) expired(e entry[T], now time.Time) bool {
+	return buf.ttl > 0 && now.Sub(e.added) > buf.ttl
 }
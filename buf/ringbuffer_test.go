@@ -7,6 +7,7 @@ import (
 	"strconv"
 	"sync"
 	"testing"
+	"time"
 )
 
 func TestRing(t *testing.T) {
@@ -43,7 +44,7 @@ func TestRing(t *testing.T) {
 }
 
 func TestRingBufferr(t *testing.T) {
-	buf := New(100)
+	buf := New[string](100)
 	sync := sync.WaitGroup{}
 	sync.Add(100)
 
@@ -55,10 +56,142 @@ func TestRingBufferr(t *testing.T) {
 
 	}
 	sync.Wait()
+
+	if buf.Len() != 100 {
+		t.Errorf("expected 100 items, got %d", buf.Len())
+	}
+}
+
+func TestRingBufferSnapshot(t *testing.T) {
+	buf := New[int](3)
+	if _, ok := buf.Last(); ok {
+		t.Error("empty buffer should have no last item")
+	}
+	for i := 1; i <= 5; i++ {
+		buf.Add(i)
+	}
+
+	if s := fmt.Sprint(buf.Snapshot()); s != "[5 4 3]" {
+		t.Errorf("unexpected snapshot %s", s)
+	}
+	if last, ok := buf.Last(); !ok || last != 5 {
+		t.Errorf("unexpected last item %d", last)
+	}
+	items := []int{}
+	buf.Do(func(i int) {
+		items = append(items, i)
+	})
+	if s := fmt.Sprint(items); s != "[3 4 5]" {
+		t.Errorf("unexpected order %s", s)
+	}
+}
+
+func TestRingBufferResize(t *testing.T) {
+	buf := New[int](3)
+	for i := 1; i <= 4; i++ {
+		buf.Add(i)
+	}
+
+	buf.Resize(2)
+	if s := fmt.Sprint(buf.Snapshot()); s != "[4 3]" {
+		t.Errorf("unexpected snapshot after shrink %s", s)
+	}
+	buf.Resize(4)
+	buf.Add(5)
+	buf.Add(6)
+	if s := fmt.Sprint(buf.Snapshot()); s != "[6 5 4 3]" {
+		t.Errorf("unexpected snapshot after grow %s", s)
+	}
+}
+
+func TestRingBufferNegativeSize(t *testing.T) {
+	buf := New[int](-1)
+	buf.Add(1)
+	if buf.Len() != 0 {
+		t.Errorf("expected empty buffer, got %v", buf.Snapshot())
+	}
+
+	buf.Resize(2)
+	buf.Add(2)
+	buf.Resize(-3)
+	if buf.Len() != 0 {
+		t.Errorf("expected empty buffer after resize, got %v", buf.Snapshot())
+	}
+	buf.Add(3)
+	if _, ok := buf.Last(); ok {
+		t.Error("buffer of zero size must not keep items")
+	}
+}
+
+func TestRingBufferDeduplication(t *testing.T) {
+	type video struct {
+		ID    string
+		Views int
+	}
+	buf := New[video](3, WithKey(func(v video) string { return v.ID }))
+	buf.Add(video{"a", 1})
+	buf.Add(video{"b", 1})
+	buf.Add(video{"c", 1})
+	buf.Add(video{"a", 2})
+
+	if s := fmt.Sprint(buf.Snapshot()); s != "[{a 2} {c 1} {b 1}]" {
+		t.Errorf("unexpected snapshot %s", s)
+	}
+	buf.Add(video{"d", 1})
+	if s := fmt.Sprint(buf.Snapshot()); s != "[{d 1} {a 2} {c 1}]" {
+		t.Errorf("unexpected snapshot %s", s)
+	}
+}
+
+func TestRingBufferTTL(t *testing.T) {
+	now := time.Now()
+	buf := New[string](3, WithTTL[string](time.Minute))
+	buf.now = func() time.Time { return now }
+
+	buf.Add("old")
+	now = now.Add(50 * time.Second)
+	buf.Add("new")
+	now = now.Add(20 * time.Second)
+
+	if s := fmt.Sprint(buf.Snapshot()); s != "[new]" {
+		t.Errorf("unexpected snapshot %s", s)
+	}
+	if buf.Len() != 1 {
+		t.Errorf("expected 1 item, got %d", buf.Len())
+	}
+	now = now.Add(time.Minute)
+	if _, ok := buf.Last(); ok {
+		t.Error("all items should expire")
+	}
+}
+
+func TestRingBufferTouch(t *testing.T) {
+	now := time.Now()
+	buf := New[string](3,
+		WithTTL[string](time.Minute),
+		WithKey(func(s string) string { return s }),
+		WithClock[string](func() time.Time { return now }))
+
+	buf.Add("a")
+	buf.Add("b")
+	now = now.Add(50 * time.Second)
+	if !buf.Touch("a") {
+		t.Error("expected a to be touched")
+	}
+	if buf.Touch("c") {
+		t.Error("missing item must not be touched")
+	}
+	now = now.Add(20 * time.Second)
+	if s := fmt.Sprint(buf.Snapshot()); s != "[a]" {
+		t.Errorf("unexpected snapshot %s", s)
+	}
+	if buf.Touch("b") {
+		t.Error("expired item must not be touched")
+	}
 }
 
 func BenchmarkRingBufferr_10000(b *testing.B) {
-	buf := New(100)
+	buf := New[string](100)
 	s1 := sync.WaitGroup{}
 	s1.Add(b.N * 2)
 
@@ -69,7 +202,7 @@ func BenchmarkRingBufferr_10000(b *testing.B) {
 		}()
 
 		go func() {
-			buf.Do(func(x interface{}) {
+			buf.Do(func(x string) {
 				fmt.Println(x)
 
 			})
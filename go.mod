@@ -3,26 +3,29 @@ module github.com/reportportal/landing-aggregator
 go 1.25.3
 
 require (
+	cloud.google.com/go/recaptchaenterprise/v2 v2.20.5
+	github.com/andybalholm/brotli v1.2.0
 	github.com/caarlos0/env/v6 v6.10.1
 	github.com/dghubble/sling v1.4.2
 	github.com/go-chi/chi/v5 v5.2.3
 	github.com/google/go-github/v50 v50.2.0
 	github.com/hanzoai/gochimp3 v0.0.0-20241127054040-6051f77e24f1
 	github.com/hashicorp/go-version v1.7.0
-	github.com/patrickmn/go-cache v2.1.0+incompatible
 	github.com/pkg/errors v0.9.1
 	github.com/reportportal/commons-go/v5 v5.0.12
 	github.com/sirupsen/logrus v1.9.3
 	golang.org/x/net v0.46.0
 	golang.org/x/oauth2 v0.32.0
+	golang.org/x/sync v0.17.0
+	golang.org/x/time v0.14.0
 	google.golang.org/api v0.254.0
+	google.golang.org/grpc v1.76.0
 )
 
 require (
 	cloud.google.com/go/auth v0.17.0 // indirect
 	cloud.google.com/go/auth/oauth2adapt v0.2.8 // indirect
 	cloud.google.com/go/compute/metadata v0.9.0 // indirect
-	cloud.google.com/go/recaptchaenterprise/v2 v2.20.5 // indirect
 	github.com/ProtonMail/go-crypto v1.3.0 // indirect
 	github.com/caarlos0/env/v10 v10.0.0 // indirect
 	github.com/cloudflare/circl v1.6.1 // indirect
@@ -46,12 +49,9 @@ require (
 	go.opentelemetry.io/otel/metric v1.38.0 // indirect
 	go.opentelemetry.io/otel/trace v1.38.0 // indirect
 	golang.org/x/crypto v0.43.0 // indirect
-	golang.org/x/sync v0.17.0 // indirect
 	golang.org/x/sys v0.37.0 // indirect
 	golang.org/x/text v0.30.0 // indirect
-	golang.org/x/time v0.14.0 // indirect
 	google.golang.org/genproto/googleapis/api v0.0.0-20251029180050-ab9386a59fda // indirect
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20251029180050-ab9386a59fda // indirect
-	google.golang.org/grpc v1.76.0 // indirect
 	google.golang.org/protobuf v1.36.10 // indirect
 )
@@ -0,0 +1,107 @@
+package info
+
+import (
+	"sort"
+
+	"github.com/reportportal/landing-aggregator/pkg/snapshot"
+)
+
+// Release is a new latest version of the repository
+type Release struct {
+	Repo     string `json:"repo"`
+	Version  string `json:"version"`
+	Previous string `json:"previous,omitempty"`
+}
+
+// StarsChange is a change of total count of stars
+type StarsChange struct {
+	Total    int `json:"total"`
+	Previous int `json:"previous"`
+}
+
+// ReleaseChanges returns releases which appeared in the latest versions snapshot
+func ReleaseChanges(prev, next *snapshot.Snapshot) []interface{} {
+	before, _ := prev.Data.(map[string]string)
+	after, _ := next.Data.(map[string]string)
+
+	repos := make([]string, 0, len(after))
+	for repo, version := range after {
+		if before[repo] != version {
+			repos = append(repos, repo)
+		}
+	}
+	sort.Strings(repos)
+
+	changes := make([]interface{}, len(repos))
+	for i, repo := range repos {
+		changes[i] = &Release{Repo: repo, Version: after[repo], Previous: before[repo]}
+	}
+	return changes
+}
+
+// StarsChanges returns change of total count of stars
+func StarsChanges(prev, next *snapshot.Snapshot) []interface{} {
+	before, ok := prev.Data.(*Stars)
+	if !ok {
+		return nil
+	}
+	after, ok := next.Data.(*Stars)
+	if !ok || after.Total == before.Total {
+		return nil
+	}
+	return []interface{}{&StarsChange{Total: after.Total, Previous: before.Total}}
+}
+
+// VideoChanges returns videos which appeared in the feed snapshot
+func VideoChanges(prev, next *snapshot.Snapshot) []interface{} {
+	before, _ := prev.Data.([]VideoInfo)
+	after, _ := next.Data.([]VideoInfo)
+
+	known := make(map[string]bool, len(before))
+	for _, video := range before {
+		known[video.ID] = true
+	}
+	changes := []interface{}{}
+	for _, video := range after {
+		if !known[video.ID] {
+			changes = append(changes, video)
+		}
+	}
+	return changes
+}
+
+// NewsChanges returns news items which appeared in the feed snapshot
+func NewsChanges(prev, next *snapshot.Snapshot) []interface{} {
+	before, _ := prev.Data.([]*TwitterInfo)
+	after, _ := next.Data.([]*TwitterInfo)
+
+	known := make(map[string]bool, len(before))
+	for _, item := range before {
+		known[item.Text] = true
+	}
+	changes := []interface{}{}
+	for _, item := range after {
+		if !known[item.Text] {
+			changes = append(changes, item)
+		}
+	}
+	return changes
+}
+
+// LiveChanges returns broadcasts which appeared or changed status, e.g. went live
+func LiveChanges(prev, next *snapshot.Snapshot) []interface{} {
+	before, _ := prev.Data.([]LiveBroadcast)
+	after, _ := next.Data.([]LiveBroadcast)
+
+	status := make(map[string]string, len(before))
+	for _, broadcast := range before {
+		status[broadcast.ID] = broadcast.Status
+	}
+	changes := []interface{}{}
+	for _, broadcast := range after {
+		if status[broadcast.ID] != broadcast.Status {
+			changes = append(changes, broadcast)
+		}
+	}
+	return changes
+}
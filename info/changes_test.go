@@ -0,0 +1,70 @@
+package info
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/reportportal/landing-aggregator/pkg/snapshot"
+)
+
+func TestReleaseChanges(t *testing.T) {
+	prev := snapshot.Static(map[string]string{"service-api": "5.0", "service-ui": "5.0"})
+	next := snapshot.Static(map[string]string{"service-api": "5.1", "service-ui": "5.0", "service-jobs": "5.0"})
+
+	expected := []interface{}{
+		&Release{Repo: "service-api", Version: "5.1", Previous: "5.0"},
+		&Release{Repo: "service-jobs", Version: "5.0"},
+	}
+	if changes := ReleaseChanges(prev, next); !reflect.DeepEqual(changes, expected) {
+		t.Errorf("unexpected changes %+v", changes)
+	}
+	if changes := ReleaseChanges(next, next); len(changes) != 0 {
+		t.Errorf("unexpected changes %+v", changes)
+	}
+}
+
+func TestStarsChanges(t *testing.T) {
+	prev := snapshot.Static(&Stars{Total: 10})
+	next := snapshot.Static(&Stars{Total: 12})
+
+	expected := []interface{}{&StarsChange{Total: 12, Previous: 10}}
+	if changes := StarsChanges(prev, next); !reflect.DeepEqual(changes, expected) {
+		t.Errorf("unexpected changes %+v", changes)
+	}
+	if changes := StarsChanges(next, snapshot.Static(&Stars{Total: 12, Repos: map[string]int{"a": 12}})); changes != nil {
+		t.Errorf("unchanged total must not be reported, got %+v", changes)
+	}
+	if changes := StarsChanges(snapshot.Static(nil), next); changes != nil {
+		t.Errorf("missing previous stars must not be reported, got %+v", changes)
+	}
+}
+
+func TestVideoChanges(t *testing.T) {
+	prev := snapshot.Static([]VideoInfo{{ID: "a"}, {ID: "b"}})
+	next := snapshot.Static([]VideoInfo{{ID: "c"}, {ID: "a"}})
+
+	expected := []interface{}{VideoInfo{ID: "c"}}
+	if changes := VideoChanges(prev, next); !reflect.DeepEqual(changes, expected) {
+		t.Errorf("unexpected changes %+v", changes)
+	}
+}
+
+func TestNewsChanges(t *testing.T) {
+	prev := snapshot.Static([]*TwitterInfo{{Text: "old"}})
+	next := snapshot.Static([]*TwitterInfo{{Text: "new"}, {Text: "old"}})
+
+	changes := NewsChanges(prev, next)
+	if len(changes) != 1 || changes[0].(*TwitterInfo).Text != "new" {
+		t.Errorf("unexpected changes %+v", changes)
+	}
+}
+
+func TestLiveChanges(t *testing.T) {
+	prev := snapshot.Static([]LiveBroadcast{{ID: "a", Status: broadcastUpcoming}, {ID: "b", Status: broadcastUpcoming}})
+	next := snapshot.Static([]LiveBroadcast{{ID: "a", Status: broadcastLive}, {ID: "b", Status: broadcastUpcoming}, {ID: "c", Status: broadcastUpcoming}})
+
+	expected := []interface{}{LiveBroadcast{ID: "a", Status: broadcastLive}, LiveBroadcast{ID: "c", Status: broadcastUpcoming}}
+	if changes := LiveChanges(prev, next); !reflect.DeepEqual(changes, expected) {
+		t.Errorf("unexpected changes %+v", changes)
+	}
+}
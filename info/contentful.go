@@ -6,9 +6,17 @@ import (
 	"io"
 	"net/http"
 	"strconv"
+	"sync"
 	"time"
 
-	"github.com/patrickmn/go-cache"
+	"github.com/reportportal/landing-aggregator/buf"
+	"github.com/reportportal/landing-aggregator/pkg/snapshot"
+)
+
+const (
+	feedCachePeriod = 2 * time.Minute
+	// feedEntryTTL is how long entry missing in Contentful responses stays in the feed
+	feedEntryTTL = 3 * feedCachePeriod
 )
 
 // CmaClient is a client for Contentful Management API
@@ -16,6 +24,10 @@ type CmaClient struct {
 	Token   string
 	SpaceID string
 	Limit   int
+
+	mu      sync.Mutex
+	entries *buf.RingBuffer[*TwitterInfo]
+	feed    *snapshot.Store
 }
 
 // NewsFeed is a struct for the Contentful News Feed
@@ -34,14 +46,16 @@ type TwitterInfo struct {
 	Entities struct{} `json:"entities"`
 }
 
-var localCache = cache.New(2*time.Minute, 5*time.Minute)
-
 // NewCma creates a new CmaClient
 func NewCma(spaceID string, token string, limit int) *CmaClient {
 	cma := &CmaClient{
 		Token:   token,
 		SpaceID: spaceID,
 		Limit:   limit,
+		entries: buf.New(limit,
+			buf.WithTTL[*TwitterInfo](feedEntryTTL),
+			buf.WithKey(func(t *TwitterInfo) string { return t.Text })),
+		feed: snapshot.NewStore(feedCachePeriod, []*TwitterInfo{}),
 	}
 	return cma
 }
@@ -108,37 +122,42 @@ func mapEntriesToTwitterFeed(entry []byte) []*TwitterInfo {
 	return tweets
 }
 
-// GetTwitterFeed provides a list of tweets from local cache or Contentful after mapping
-func GetTwitterFeed(cma *CmaClient, count int) []*TwitterInfo {
-	contentType := "newsFeed"
-	cacheKey := contentType + cma.SpaceID
-
-	if cachedEntry, found := localCache.Get(cacheKey); found {
-		// Entry found in the cache, use it
-		entry := cachedEntry.([]*TwitterInfo)
+// FeedSnapshot returns snapshot of the news feed. Feed is fetched from Contentful when cached one expires,
+// previous feed is kept if Contentful cannot be reached. While the feed is being fetched, other callers get
+// the expired feed instead of waiting for Contentful; only the very first fetch is waited for
+func (cma *CmaClient) FeedSnapshot() *snapshot.Snapshot {
+	current := cma.feed.Load()
+	if current.Fresh() > 0 {
+		return current
+	}
 
-		// Debugging info
-		// fmt.Printf("\nEntry fetched from local cache: \n%s\n", entry)
+	if current.Refreshed.IsZero() {
+		cma.mu.Lock()
+	} else if !cma.mu.TryLock() {
+		return current
+	}
+	defer cma.mu.Unlock()
+	// feed could be refreshed while waiting for the lock
+	if current := cma.feed.Load(); current.Fresh() > 0 {
+		return current
+	}
 
-		if count >= len(entry) {
-			return entry
+	body := FetchEntriesFromContentful("newsFeed", cma.SpaceID, cma.Token, strconv.Itoa(cma.Limit))
+	if tweets := mapEntriesToTwitterFeed(body); tweets != nil {
+		// add the oldest first, so that the buffer keeps order of Contentful response
+		for i := len(tweets) - 1; i >= 0; i-- {
+			cma.entries.Add(tweets[i])
 		}
-
-		return entry[0:count]
+		cma.feed.Set(cma.entries.Snapshot())
+	} else {
+		cma.feed.Touch()
 	}
+	return cma.feed.Load()
+}
 
-	// Entry not found in the cache, fetch it from Contentful
-	body := FetchEntriesFromContentful(contentType, cma.SpaceID, cma.Token, strconv.Itoa(cma.Limit))
-
-	// Map the fetched entry to a NewsFeed struct
-	tweets := mapEntriesToTwitterFeed(body)
-
-	// Store the fetched entry in the cache
-	localCache.Set(cacheKey, tweets, cache.DefaultExpiration)
-
-	// Debugging info
-	// fmt.Printf("\nEntry fetched from Contentful: \n%s\n", tweets)
-
+// GetTwitterFeed provides up to count tweets of the news feed snapshot
+func GetTwitterFeed(feed *snapshot.Snapshot, count int) []*TwitterInfo {
+	tweets := feed.Data.([]*TwitterInfo)
 	if count >= len(tweets) {
 		return tweets
 	}
@@ -14,6 +14,7 @@ import (
 	"github.com/google/go-github/v50/github"
 	"github.com/hashicorp/go-version"
 	"github.com/reportportal/commons-go/v5/commons"
+	"github.com/reportportal/landing-aggregator/pkg/snapshot"
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/oauth2"
 )
@@ -48,11 +49,11 @@ const (
 type GitHubAggregator struct {
 	c *github.Client
 
-	repos              atomic.Value
-	latestTags         atomic.Value
-	commitStats        atomic.Value
-	uniqueContributors atomic.Value
-	issueStats         atomic.Value
+	repos        atomic.Value
+	stars        *snapshot.Store
+	latestTags   *snapshot.Store
+	contribution *snapshot.Store
+	issueStats   *snapshot.Store
 }
 
 // ContributionStats contains aggregated info related to contribution to a organization repositories
@@ -83,20 +84,19 @@ func NewGitHubAggregator(ghToken string, includeBeta bool) *GitHubAggregator {
 
 	ghClient := github.NewClient(oauth2.NewClient(context.Background(), ts))
 	stats := &GitHubAggregator{
-		c:                  ghClient,
-		latestTags:         atomic.Value{},
-		repos:              atomic.Value{},
-		commitStats:        atomic.Value{},
-		uniqueContributors: atomic.Value{},
-		issueStats:         atomic.Value{},
+		c:          ghClient,
+		repos:      atomic.Value{},
+		stars:      snapshot.NewStore(repoSyncPeriod, &Stars{Repos: map[string]int{}}),
+		latestTags: snapshot.NewStore(versionsSyncPeriod, map[string]string{}),
+		contribution: snapshot.NewStore(commitsStatsSyncPeriod, &ContributionStats{
+			Commits:      map[StatRange]int{},
+			Contributors: map[StatRange]int{},
+		}),
+		issueStats: snapshot.NewStore(issuesStatsSyncPeriod, &IssueStats{}),
 	}
 
 	//initial empty values for atomic stores
 	stats.repos.Store([]*github.Repository{})
-	stats.latestTags.Store(map[string]string{})
-	stats.commitStats.Store(map[StatRange]int{})
-	stats.uniqueContributors.Store(map[StatRange]int{})
-	stats.issueStats.Store(&IssueStats{})
 
 	//schedules updates of repos
 	stats.loadRepos()
@@ -161,7 +161,9 @@ func (s *GitHubAggregator) loadCommitStats() {
 		}
 	})
 
-	s.commitStats.Store(commitStats)
+	s.contribution.Update(func(current interface{}) interface{} {
+		return &ContributionStats{Commits: commitStats, Contributors: current.(*ContributionStats).Contributors}
+	})
 }
 func (s *GitHubAggregator) loadUniqueContributors() {
 	log.Debugf("Updating unique contributors set...")
@@ -207,7 +209,9 @@ func (s *GitHubAggregator) loadUniqueContributors() {
 		}
 	})
 
-	s.uniqueContributors.Store(uniqueContributors)
+	s.contribution.Update(func(current interface{}) interface{} {
+		return &ContributionStats{Commits: current.(*ContributionStats).Commits, Contributors: uniqueContributors}
+	})
 
 }
 
@@ -253,7 +257,7 @@ func (s *GitHubAggregator) loadVersionsMap(includeBeta bool) {
 		}
 	})
 
-	s.latestTags.Store(versionMap)
+	s.latestTags.Set(versionMap)
 }
 
 // loadIssueStats loads issue statistics
@@ -275,7 +279,7 @@ func (s *GitHubAggregator) loadIssueStats() {
 		log.Errorf("Unable to find PRs count. %s", err.Error())
 	}
 
-	s.issueStats.Store(&IssueStats{
+	s.issueStats.Set(&IssueStats{
 		OpenPRs:      prs.GetTotal(),
 		OpenIssues:   issues.GetTotal(),
 		ClosedIssues: closedIssues.GetTotal(),
@@ -340,6 +344,7 @@ func (s *GitHubAggregator) loadRepos() {
 	}
 	log.Infof("%d repositories found", len(allRepos))
 	s.repos.Store(allRepos)
+	s.stars.Set(countStars(allRepos))
 
 }
 
@@ -352,16 +357,9 @@ func (s *GitHubAggregator) scheduleReposReload(after time.Duration) {
 	})
 }
 
-// GetLatestTags returns copy of latest versions/tags map
-func (s *GitHubAggregator) GetLatestTags() map[string]string {
-	return s.latestTags.Load().(map[string]string)
-}
-
-// GetStars returns count of stars for each repository and total count
-func (s *GitHubAggregator) GetStars() *Stars {
+// countStars counts stars for each repository and total count
+func countStars(repos []*github.Repository) *Stars {
 	total := 0
-	repos := s.repos.Load().([]*github.Repository)
-
 	repoStars := make(map[string]int, len(repos))
 	for _, repo := range repos {
 		repoStars[repo.GetName()] = repo.GetStargazersCount()
@@ -370,12 +368,42 @@ func (s *GitHubAggregator) GetStars() *Stars {
 	return &Stars{Total: total, Repos: repoStars}
 }
 
+// GetLatestTags returns copy of latest versions/tags map
+func (s *GitHubAggregator) GetLatestTags() map[string]string {
+	return s.latestTags.Load().Data.(map[string]string)
+}
+
+// GetStars returns count of stars for each repository and total count
+func (s *GitHubAggregator) GetStars() *Stars {
+	return s.stars.Load().Data.(*Stars)
+}
+
 // GetContributionStats returns aggregated contribution stats for organization repositories
 func (s *GitHubAggregator) GetContributionStats() *ContributionStats {
-	return &ContributionStats{Commits: s.commitStats.Load().(map[StatRange]int), Contributors: s.uniqueContributors.Load().(map[StatRange]int)}
+	return s.contribution.Load().Data.(*ContributionStats)
 }
 
 // GetIssueStats returns issues/PRs statistics
 func (s *GitHubAggregator) GetIssueStats() *IssueStats {
-	return s.issueStats.Load().(*IssueStats)
+	return s.issueStats.Load().Data.(*IssueStats)
+}
+
+// LatestTagsSnapshot returns current snapshot of latest versions
+func (s *GitHubAggregator) LatestTagsSnapshot() *snapshot.Snapshot {
+	return s.latestTags.Load()
+}
+
+// StarsSnapshot returns current snapshot of stars
+func (s *GitHubAggregator) StarsSnapshot() *snapshot.Snapshot {
+	return s.stars.Load()
+}
+
+// ContributionSnapshot returns current snapshot of contribution stats
+func (s *GitHubAggregator) ContributionSnapshot() *snapshot.Snapshot {
+	return s.contribution.Load()
+}
+
+// IssueStatsSnapshot returns current snapshot of issues/PRs statistics
+func (s *GitHubAggregator) IssueStatsSnapshot() *snapshot.Snapshot {
+	return s.issueStats.Load()
 }
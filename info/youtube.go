@@ -2,13 +2,15 @@ package info
 
 import (
 	"strings"
-	"sync/atomic"
+	"sync"
 	"time"
 
 	"google.golang.org/api/option"
 
 	"github.com/pkg/errors"
 	"github.com/reportportal/commons-go/v5/commons"
+	"github.com/reportportal/landing-aggregator/buf"
+	"github.com/reportportal/landing-aggregator/pkg/snapshot"
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
 	"google.golang.org/api/googleapi"
@@ -19,17 +21,41 @@ import (
 
 const (
 	videosListSyncPeriod = time.Hour * 2
+	// videosStatsSyncPeriod is the refresh period of view, like and comment counts
+	videosStatsSyncPeriod = 15 * time.Minute
+	// videoTTL is how long video missing in the feed responses stays in the buffer
+	videoTTL = 24 * time.Hour
+	// maxPageSize is the maximum number of items YouTube API returns per page
+	maxPageSize = 50
 )
 
+// YoutubeFeed describes source of the videos: latest uploads of the channel or items of the playlist
+type YoutubeFeed struct {
+	Name       string
+	ChannelID  string
+	PlaylistID string
+	Size       int
+	// ImageProxyURL is a base URL of the image proxy thumbnails are served from, i.ytimg.com is used when empty
+	ImageProxyURL string
+}
+
 // YoutubeBuffer represents buffer of videos
 type YoutubeBuffer struct {
 	youtube   *youtube.Service
-	channelID string
+	quota     *YoutubeQuota
+	rss       *YoutubeRSS
+	feed      YoutubeFeed
 	cacheSize int64
+	uploadsID string
 
-	info       atomic.Value
-	searchETag string
-	videosETag string
+	// mu serializes listing and statistics refreshes
+	mu        sync.Mutex
+	videos    *buf.RingBuffer[VideoInfo]
+	info      *snapshot.Store
+	listETag  string
+	statsETag string
+	// listed are IDs of the videos of the last loaded list, they are confirmed by not modified responses
+	listed []string
 }
 
 // VideoInfo represents video details
@@ -40,6 +66,14 @@ type VideoInfo struct {
 	Duration    string                   `json:"duration,omitempty"`
 	PublishedAt string                   `json:"published_at"`
 	Statistics  Statistics               `json:"statistics,omitempty"`
+
+	DurationSeconds int64    `json:"duration_seconds,omitempty"`
+	DurationText    string   `json:"duration_text,omitempty"`
+	Short           *bool    `json:"short,omitempty"`
+	Description     string   `json:"description,omitempty"`
+	Tags            []string `json:"tags,omitempty"`
+	Language        string   `json:"default_language,omitempty"`
+	Captions        *bool    `json:"captions,omitempty"`
 }
 
 // Statistics represents video statistics
@@ -49,39 +83,62 @@ type Statistics struct {
 	ViewCount    uint64 `json:"view_count,omitempty"`
 }
 
-// NewYoutubeVideosBuffer creates new buffer of YouTube videos info
-func NewYoutubeVideosBuffer(
-	channelID string,
-	cacheSize int,
-	apiKey string,
-) (*YoutubeBuffer, error) {
-	ctx := context.Background()
-	srv, err := youtube.NewService(ctx, option.WithAPIKey(apiKey))
+// NewYoutubeService creates YouTube Data API client shared by the buffers
+func NewYoutubeService(apiKey string) (*youtube.Service, error) {
+	srv, err := youtube.NewService(context.Background(), option.WithAPIKey(apiKey))
 	if nil != err {
 		return nil, errors.Wrap(err, "Cannot build Youtube service")
 	}
+	return srv, nil
+}
+
+// NewYoutubeVideosBuffer creates new buffer of YouTube videos info of the feed.
+// Videos are read from the Data API when srv is provided, RSS reader is used without it
+// and as a fallback when the Data API call fails
+func NewYoutubeVideosBuffer(srv *youtube.Service, quota *YoutubeQuota, rss *YoutubeRSS, feed YoutubeFeed) (*YoutubeBuffer, error) {
+	if feed.ChannelID == "" && feed.PlaylistID == "" {
+		return nil, errors.Errorf("Youtube feed %q has neither channel nor playlist", feed.Name)
+	}
+	if srv == nil && rss == nil {
+		return nil, errors.Errorf("Youtube feed %q has no video provider", feed.Name)
+	}
 	buffer := &YoutubeBuffer{
 		youtube:   srv,
-		channelID: channelID,
-		cacheSize: int64(cacheSize),
+		quota:     quota,
+		rss:       rss,
+		feed:      feed,
+		cacheSize: int64(feed.Size),
+		videos: buf.New(feed.Size,
+			buf.WithTTL[VideoInfo](videoTTL),
+			buf.WithKey(func(v VideoInfo) string { return v.ID })),
+		info: snapshot.NewStore(videosStatsSyncPeriod, []VideoInfo{}),
 	}
 
-	//schedules updates of latest versions
+	//schedules updates of the list of videos and their statistics
 	buffer.loadVideos()
-	commons.Schedule(videosListSyncPeriod, true, func() {
-		buffer.loadVideos()
-	})
+	if srv == nil {
+		// RSS feeds are free and carry view counts, so they are read as often as statistics
+		commons.Schedule(videosStatsSyncPeriod, false, buffer.loadVideos)
+	} else {
+		commons.Schedule(videosListSyncPeriod, false, buffer.loadVideos)
+		commons.Schedule(videosStatsSyncPeriod, false, buffer.loadStatistics)
+	}
 	return buffer, nil
 }
 
+// Feed returns description of the buffered feed
+func (y *YoutubeBuffer) Feed() YoutubeFeed {
+	return y.feed
+}
+
 // GetAllVideos returns all videos available in the buffer
 func (y *YoutubeBuffer) GetAllVideos() []VideoInfo {
-	return y.info.Load().([]VideoInfo)
+	return y.info.Load().Data.([]VideoInfo)
 }
 
 // GetVideos returns slice with specified count of videos
 func (y *YoutubeBuffer) GetVideos(c int) []VideoInfo {
-	items, ok := y.info.Load().([]VideoInfo)
+	items, ok := y.info.Load().Data.([]VideoInfo)
 	if !ok {
 		return []VideoInfo{}
 	}
@@ -91,64 +148,297 @@ func (y *YoutubeBuffer) GetVideos(c int) []VideoInfo {
 	return items[0:c]
 }
 
+// loadVideos refreshes list of videos. Cached videos are kept unless the new list is loaded successfully
 func (y *YoutubeBuffer) loadVideos() {
+	y.mu.Lock()
+	defer y.mu.Unlock()
+
+	videos, err := y.fetchVideos()
+	switch {
+	case googleapi.IsNotModified(err):
+		log.Debugf("No new videos in %s feed", y.feed.Name)
+		// listed videos still exist, so their TTL is renewed
+		y.touchVideos(y.listed)
+		y.info.Touch()
+	case errors.Is(err, ErrQuotaExhausted):
+		log.Warnf("Skipping refresh of %s feed: %v", y.feed.Name, err)
+	case nil != err:
+		log.Errorf("Error loading videos of %s feed, keeping %d cached: %v", y.feed.Name, len(y.GetAllVideos()), err)
+	default:
+		log.Infof("Loaded %d video details of %s feed", len(videos), y.feed.Name)
+		// add the oldest first, so that the buffer keeps order of the feed
+		for i := len(videos) - 1; i >= 0; i-- {
+			video := videos[i]
+			video.Thumbnail = proxyThumbnails(y.feed.ImageProxyURL, video.ID, video.Thumbnail)
+			y.videos.Add(video)
+		}
+		y.info.Set(y.videos.Snapshot())
+	}
+}
+
+// loadStatistics refreshes statistics of the cached videos
+func (y *YoutubeBuffer) loadStatistics() {
+	y.mu.Lock()
+	defer y.mu.Unlock()
+
+	current := y.videos.Snapshot()
+	if len(current) == 0 {
+		return
+	}
+	ids := make([]string, len(current))
+	for i, video := range current {
+		ids[i] = video.ID
+	}
+
+	items, etag, err := y.listVideos([]string{"statistics"}, ids, y.statsETag)
+	switch {
+	case googleapi.IsNotModified(err):
+		// statistics are not modified for the same IDs only, so all of them still exist
+		y.touchVideos(ids)
+		y.info.Touch()
+		return
+	case nil != err:
+		log.Warnf("Error loading statistics of %s feed: %v", y.feed.Name, err)
+		return
+	}
+	y.statsETag = etag
+
+	stats := make(map[string]Statistics, len(items))
+	for _, item := range items {
+		if item.Statistics != nil {
+			stats[item.Id] = newStatistics(item.Statistics)
+		}
+	}
+	// videos returned by the Data API still exist, so they are re-added in the same order to renew their TTL
+	for i := len(current) - 1; i >= 0; i-- {
+		if s, ok := stats[current[i].ID]; ok {
+			video := current[i]
+			video.Statistics = s
+			y.videos.Add(video)
+		}
+	}
+	y.info.Set(y.videos.Snapshot())
+}
+
+// touchVideos renews TTL of the buffered videos confirmed by the Data API
+func (y *YoutubeBuffer) touchVideos(ids []string) {
+	for _, id := range ids {
+		y.videos.Touch(id)
+	}
+}
+
+// fetchVideos reads videos from the Data API falling back to the RSS feed on failure
+func (y *YoutubeBuffer) fetchVideos() ([]VideoInfo, error) {
+	if y.youtube == nil {
+		return y.rss.Videos(y.feed, int(y.cacheSize))
+	}
 	videos, err := y.getVideos()
+	if nil == err || googleapi.IsNotModified(err) || y.rss == nil {
+		return videos, err
+	}
+
+	log.Warnf("Falling back to RSS feed of %s: %v", y.feed.Name, err)
+	// RSS misses some details, so next Data API response must not be treated as not modified
+	y.listETag = ""
+	return y.rss.Videos(y.feed, int(y.cacheSize))
+}
+
+// Snapshot returns current snapshot of videos
+func (y *YoutubeBuffer) Snapshot() *snapshot.Snapshot {
+	return y.info.Load()
+}
+
+// listVideoIDs returns IDs of the latest channel uploads or playlist items and ETag of the list
+func (y *YoutubeBuffer) listVideoIDs() ([]string, string, error) {
+	playlistID, err := y.playlistID()
 	if nil != err {
-		if googleapi.IsNotModified(err) {
-			log.Info("No new videos find")
-			return
+		return nil, "", err
+	}
+
+	ids := []string{}
+	etag := ""
+	pageToken := ""
+	for int64(len(ids)) < y.cacheSize {
+		if !y.quota.Take(quotaCostList) {
+			return nil, "", ErrQuotaExhausted
+		}
+		call := y.youtube.PlaylistItems.List([]string{"contentDetails"}).
+			PlaylistId(playlistID).
+			Fields("etag,nextPageToken,items(contentDetails/videoId)").
+			MaxResults(min(y.cacheSize-int64(len(ids)), maxPageSize))
+		if pageToken == "" {
+			// only the first page tells whether playlist has changed
+			call = call.IfNoneMatch(y.listETag)
+		} else {
+			call = call.PageToken(pageToken)
+		}
+		rs, err := call.Do()
+		if nil != err {
+			return nil, "", err
 		}
-		log.Errorf("Error loading videos: %v", err)
+		if pageToken == "" {
+			etag = rs.Etag
+		}
+
+		for _, item := range rs.Items {
+			ids = append(ids, item.ContentDetails.VideoId)
+		}
+		if rs.NextPageToken == "" {
+			break
+		}
+		pageToken = rs.NextPageToken
 	}
-	log.Infof("Loaded %d video details", len(videos))
-	y.info.Store(videos)
+	return ids, etag, nil
 }
-func (y *YoutubeBuffer) getVideos() ([]VideoInfo, error) {
-	call := y.youtube.Search.List([]string{"snippet"})
-	call = call.
-		ChannelId(y.channelID).
-		Fields("items(id/videoId)").
-		Order("date").
-		Type("video").
-		MaxResults(y.cacheSize).
-		IfNoneMatch(y.searchETag)
-	searchRS, err := call.Do()
+
+// playlistID returns ID of the feed playlist. Uploads playlist of the channel is resolved once
+func (y *YoutubeBuffer) playlistID() (string, error) {
+	if y.feed.PlaylistID != "" {
+		return y.feed.PlaylistID, nil
+	}
+	if y.uploadsID != "" {
+		return y.uploadsID, nil
+	}
+
+	uploadsID, err := uploadsPlaylistID(y.youtube, y.quota, y.feed.ChannelID)
 	if nil != err {
-		return nil, err
+		return "", err
 	}
-	y.searchETag = searchRS.Etag
+	y.uploadsID = uploadsID
+	return y.uploadsID, nil
+}
 
-	ids := make([]string, len(searchRS.Items))
-	for i, item := range searchRS.Items {
-		ids[i] = item.Id.VideoId
+// uploadsPlaylistID resolves ID of the playlist holding all uploads of the channel
+func uploadsPlaylistID(srv *youtube.Service, quota *YoutubeQuota, channelID string) (string, error) {
+	if !quota.Take(quotaCostList) {
+		return "", ErrQuotaExhausted
 	}
-	rs, err := y.youtube.Videos.
-		List([]string{"snippet,contentDetails,statistics"}).
-		Id(strings.Join(ids, ",")).
-		IfNoneMatch(y.videosETag).
-		MaxResults(y.cacheSize).
+	rs, err := srv.Channels.List([]string{"contentDetails"}).
+		Id(channelID).
+		Fields("items(contentDetails/relatedPlaylists/uploads)").
 		Do()
+	if nil != err {
+		return "", err
+	}
+	if len(rs.Items) == 0 || rs.Items[0].ContentDetails == nil || rs.Items[0].ContentDetails.RelatedPlaylists == nil {
+		return "", errors.Errorf("Youtube channel %s not found", channelID)
+	}
+	return rs.Items[0].ContentDetails.RelatedPlaylists.Uploads, nil
+}
+
+// getVideos loads details of the listed videos. The list ETag is stored only once details are loaded,
+// so that failed refresh is retried in full
+func (y *YoutubeBuffer) getVideos() ([]VideoInfo, error) {
+	ids, etag, err := y.listVideoIDs()
+	if nil != err {
+		return nil, err
+	}
+	items, _, err := y.listVideos([]string{"snippet", "contentDetails", "statistics"}, ids, "")
 	if nil != err {
 		return nil, err
 	}
-	y.videosETag = rs.Etag
+	y.listETag = etag
+	// ETag depends on requested parts and IDs, statistics of the new list are never cached
+	y.statsETag = ""
 
-	videos := make([]VideoInfo, len(rs.Items))
-	for i, video := range rs.Items {
-		videos[i] = VideoInfo{
-			ID:          video.Id,
-			Title:       video.Snippet.Title,
-			PublishedAt: video.Snippet.PublishedAt,
-			Duration:    video.ContentDetails.Duration,
-			Thumbnail:   *video.Snippet.Thumbnails,
-			Statistics: Statistics{
-				CommentCount: video.Statistics.CommentCount,
-				LikeCount:    video.Statistics.LikeCount,
-				ViewCount:    video.Statistics.ViewCount,
-			},
+	videos := make([]VideoInfo, 0, len(items))
+	y.listed = make([]string, 0, len(items))
+	for _, video := range items {
+		// live and upcoming broadcasts are served by YoutubeLive
+		if isBroadcast(video) {
+			continue
 		}
+		videos = append(videos, newVideoInfo(video))
+		y.listed = append(y.listed, video.Id)
 	}
-
 	return videos, nil
+}
+
+// listVideos reads resources of the videos by pages of maxPageSize IDs.
+// ETag is used only when all the IDs fit into a single page
+func (y *YoutubeBuffer) listVideos(parts, ids []string, etag string) ([]*youtube.Video, string, error) {
+	items := []*youtube.Video{}
+	newETag := ""
+	single := len(ids) <= maxPageSize
+	for start := 0; start < len(ids); start += maxPageSize {
+		if !y.quota.Take(quotaCostList) {
+			return nil, "", ErrQuotaExhausted
+		}
+		call := y.youtube.Videos.List(parts).Id(strings.Join(ids[start:min(start+maxPageSize, len(ids))], ","))
+		if single {
+			call = call.IfNoneMatch(etag)
+		}
+		rs, err := call.Do()
+		if nil != err {
+			return nil, "", err
+		}
+		if single {
+			newETag = rs.Etag
+		}
+		items = append(items, rs.Items...)
+	}
+	return items, newETag, nil
+}
 
+// newVideoInfo maps Data API video resource to VideoInfo
+func newVideoInfo(video *youtube.Video) VideoInfo {
+	info := VideoInfo{
+		ID:          video.Id,
+		Title:       video.Snippet.Title,
+		PublishedAt: video.Snippet.PublishedAt,
+		Description: excerpt(video.Snippet.Description),
+		Tags:        video.Snippet.Tags,
+		Language:    video.Snippet.DefaultLanguage,
+	}
+	if info.Language == "" {
+		info.Language = video.Snippet.DefaultAudioLanguage
+	}
+	if video.Snippet.Thumbnails != nil {
+		info.Thumbnail = *video.Snippet.Thumbnails
+	}
+	if details := video.ContentDetails; details != nil {
+		info.Duration = details.Duration
+		captions := details.Caption == "true"
+		info.Captions = &captions
+		if d, err := parseDuration(details.Duration); err == nil {
+			info.DurationSeconds = int64(d / time.Second)
+			info.DurationText = formatDuration(d)
+			short := d > 0 && d < shortsMaxDuration
+			info.Short = &short
+		}
+	}
+	if video.Statistics != nil {
+		info.Statistics = newStatistics(video.Statistics)
+	}
+	return info
+}
+
+// proxyThumbnails points thumbnails of the video at the image proxy
+func proxyThumbnails(proxyURL, videoID string, thumbnails youtube.ThumbnailDetails) youtube.ThumbnailDetails {
+	if proxyURL == "" {
+		return thumbnails
+	}
+	proxied := func(thumbnail *youtube.Thumbnail, size string) *youtube.Thumbnail {
+		if thumbnail == nil {
+			return nil
+		}
+		p := *thumbnail
+		p.Url = strings.TrimSuffix(proxyURL, "/") + "/images/youtube/" + videoID + "/" + size
+		return &p
+	}
+	return youtube.ThumbnailDetails{
+		Default:  proxied(thumbnails.Default, "default"),
+		Medium:   proxied(thumbnails.Medium, "medium"),
+		High:     proxied(thumbnails.High, "high"),
+		Standard: proxied(thumbnails.Standard, "standard"),
+		Maxres:   proxied(thumbnails.Maxres, "maxres"),
+	}
+}
+
+func newStatistics(stats *youtube.VideoStatistics) Statistics {
+	return Statistics{
+		CommentCount: stats.CommentCount,
+		LikeCount:    stats.LikeCount,
+		ViewCount:    stats.ViewCount,
+	}
 }
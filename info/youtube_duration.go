@@ -0,0 +1,96 @@
+package info
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// shortsMaxDuration is the duration below which video is considered a short
+	shortsMaxDuration = time.Minute
+	// descriptionExcerptLength is the maximum number of characters in description excerpt
+	descriptionExcerptLength = 200
+)
+
+// parseDuration parses ISO-8601 duration of a video, e.g. PT1H2M3S or P1DT3S
+func parseDuration(iso string) (time.Duration, error) {
+	rest, ok := strings.CutPrefix(iso, "P")
+	if !ok || rest == "" || strings.HasSuffix(rest, "T") {
+		return 0, errors.Errorf("invalid duration %q", iso)
+	}
+
+	var d time.Duration
+	inTime := false
+	number := ""
+	for _, r := range rest {
+		switch {
+		case r == 'T':
+			if inTime || number != "" {
+				return 0, errors.Errorf("invalid duration %q", iso)
+			}
+			inTime = true
+			continue
+		case unicode.IsDigit(r):
+			number += string(r)
+			continue
+		}
+
+		n, err := strconv.Atoi(number)
+		if err != nil {
+			return 0, errors.Errorf("invalid duration %q", iso)
+		}
+		number = ""
+
+		var unit time.Duration
+		switch {
+		case !inTime && r == 'W':
+			unit = 7 * 24 * time.Hour
+		case !inTime && r == 'D':
+			unit = 24 * time.Hour
+		case inTime && r == 'H':
+			unit = time.Hour
+		case inTime && r == 'M':
+			unit = time.Minute
+		case inTime && r == 'S':
+			unit = time.Second
+		default:
+			return 0, errors.Errorf("invalid duration %q", iso)
+		}
+		d += time.Duration(n) * unit
+	}
+	if number != "" {
+		return 0, errors.Errorf("invalid duration %q", iso)
+	}
+	return d, nil
+}
+
+// formatDuration formats duration the way YouTube player does, e.g. 12:03 or 1:02:03
+func formatDuration(d time.Duration) string {
+	seconds := int(d / time.Second)
+	h, m, s := seconds/3600, seconds/60%60, seconds%60
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%d:%02d", m, s)
+}
+
+// excerpt returns the first paragraph of the description cut at word boundary
+func excerpt(description string) string {
+	text, _, _ := strings.Cut(strings.TrimSpace(description), "\n")
+	text = strings.TrimSpace(text)
+
+	runes := []rune(text)
+	if len(runes) <= descriptionExcerptLength {
+		return text
+	}
+	cut := string(runes[:descriptionExcerptLength])
+	if i := strings.LastIndexFunc(cut, unicode.IsSpace); i > 0 {
+		cut = cut[:i]
+	}
+	return strings.TrimRightFunc(cut, unicode.IsPunct) + "…"
+}
@@ -0,0 +1,60 @@
+package info
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	cases := map[string]time.Duration{
+		"PT12M3S":  12*time.Minute + 3*time.Second,
+		"PT1H2M3S": time.Hour + 2*time.Minute + 3*time.Second,
+		"PT45S":    45 * time.Second,
+		"PT2H":     2 * time.Hour,
+		"P1DT3S":   24*time.Hour + 3*time.Second,
+		"P0D":      0,
+		"P1W":      7 * 24 * time.Hour,
+	}
+	for iso, expected := range cases {
+		d, err := parseDuration(iso)
+		if err != nil {
+			t.Errorf("cannot parse %q: %v", iso, err)
+			continue
+		}
+		if d != expected {
+			t.Errorf("%q: expected %v, got %v", iso, expected, d)
+		}
+	}
+
+	for _, invalid := range []string{"", "P", "12M", "PT", "PT5", "P1H", "PTT1S", "PT10M0.5S"} {
+		if _, err := parseDuration(invalid); err == nil {
+			t.Errorf("%q should be invalid", invalid)
+		}
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	cases := map[time.Duration]string{
+		12*time.Minute + 3*time.Second:            "12:03",
+		45 * time.Second:                          "0:45",
+		time.Hour + 2*time.Minute + 3*time.Second: "1:02:03",
+	}
+	for d, expected := range cases {
+		if actual := formatDuration(d); actual != expected {
+			t.Errorf("%v: expected %s, got %s", d, expected, actual)
+		}
+	}
+}
+
+func TestExcerpt(t *testing.T) {
+	if e := excerpt("Short intro\nLinks and details"); e != "Short intro" {
+		t.Errorf("unexpected excerpt %q", e)
+	}
+
+	long := strings.Repeat("word ", 60)
+	e := excerpt(long)
+	if !strings.HasSuffix(e, "word…") || len([]rune(e)) > descriptionExcerptLength+1 {
+		t.Errorf("unexpected excerpt %q", e)
+	}
+}
@@ -0,0 +1,193 @@
+package info
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/reportportal/landing-aggregator/pkg/snapshot"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/api/youtube/v3"
+)
+
+const (
+	liveSyncPeriod     = 30 * time.Minute
+	liveNearSyncPeriod = 2 * time.Minute
+	// liveNearWindow is how long before and after the scheduled start refreshes are frequent
+	liveNearWindow = time.Hour
+
+	broadcastLive     = "live"
+	broadcastUpcoming = "upcoming"
+)
+
+// LiveBroadcast represents live stream or upcoming premiere of the channel
+type LiveBroadcast struct {
+	ID                string                   `json:"id"`
+	Title             string                   `json:"title"`
+	Thumbnail         youtube.ThumbnailDetails `json:"thumbnail,omitempty"`
+	Status            string                   `json:"status"`
+	ScheduledStart    string                   `json:"scheduled_start,omitempty"`
+	ActualStart       string                   `json:"actual_start,omitempty"`
+	ConcurrentViewers uint64                   `json:"concurrent_viewers,omitempty"`
+}
+
+// YoutubeLive keeps live and upcoming broadcasts of the channel.
+// Broadcasts are refreshed every liveSyncPeriod and every liveNearSyncPeriod
+// while a broadcast is live or about to start
+type YoutubeLive struct {
+	youtube   *youtube.Service
+	quota     *YoutubeQuota
+	channelID string
+	uploadsID string
+	proxyURL  string
+
+	info *snapshot.Store
+}
+
+// NewYoutubeLive creates buffer of live broadcasts of the channel and schedules its updates.
+// Thumbnails are served from the image proxy when its URL is provided
+func NewYoutubeLive(srv *youtube.Service, quota *YoutubeQuota, channelID, imageProxyURL string) (*YoutubeLive, error) {
+	if srv == nil {
+		return nil, errors.New("Youtube live broadcasts require Data API")
+	}
+	live := &YoutubeLive{
+		youtube:   srv,
+		quota:     quota,
+		channelID: channelID,
+		proxyURL:  imageProxyURL,
+		info:      snapshot.NewStore(liveSyncPeriod, []LiveBroadcast{}),
+	}
+
+	go func() {
+		for {
+			time.Sleep(live.loadBroadcasts())
+		}
+	}()
+	return live, nil
+}
+
+// GetBroadcasts returns live broadcasts followed by upcoming ones ordered by scheduled start
+func (l *YoutubeLive) GetBroadcasts() []LiveBroadcast {
+	return l.info.Load().Data.([]LiveBroadcast)
+}
+
+// Snapshot returns current snapshot of broadcasts
+func (l *YoutubeLive) Snapshot() *snapshot.Snapshot {
+	return l.info.Load()
+}
+
+// loadBroadcasts refreshes broadcasts and returns delay until the next refresh
+func (l *YoutubeLive) loadBroadcasts() time.Duration {
+	broadcasts, err := l.getBroadcasts()
+	if nil != err {
+		// keep previous broadcasts, they are still the best known state
+		log.Errorf("Error loading live broadcasts: %v", err)
+		l.info.Touch()
+		return l.info.Load().Period
+	}
+
+	period := liveSyncPeriod
+	if broadcastNear(broadcasts, time.Now()) {
+		period = liveNearSyncPeriod
+	}
+	l.info.SetPeriod(period)
+	l.info.Set(broadcasts)
+	log.Debugf("Loaded %d live broadcasts", len(broadcasts))
+	return period
+}
+
+func (l *YoutubeLive) getBroadcasts() ([]LiveBroadcast, error) {
+	if l.uploadsID == "" {
+		uploadsID, err := uploadsPlaylistID(l.youtube, l.quota, l.channelID)
+		if nil != err {
+			return nil, err
+		}
+		l.uploadsID = uploadsID
+	}
+
+	if !l.quota.Take(quotaCostList) {
+		return nil, ErrQuotaExhausted
+	}
+	items, err := l.youtube.PlaylistItems.List([]string{"contentDetails"}).
+		PlaylistId(l.uploadsID).
+		Fields("items(contentDetails/videoId)").
+		MaxResults(maxPageSize).
+		Do()
+	if nil != err {
+		return nil, err
+	}
+	ids := make([]string, len(items.Items))
+	for i, item := range items.Items {
+		ids[i] = item.ContentDetails.VideoId
+	}
+
+	if !l.quota.Take(quotaCostList) {
+		return nil, ErrQuotaExhausted
+	}
+	rs, err := l.youtube.Videos.
+		List([]string{"snippet", "liveStreamingDetails"}).
+		Id(strings.Join(ids, ",")).
+		Do()
+	if nil != err {
+		return nil, err
+	}
+
+	broadcasts := []LiveBroadcast{}
+	for _, video := range rs.Items {
+		if !isBroadcast(video) {
+			continue
+		}
+		broadcast := LiveBroadcast{
+			ID:     video.Id,
+			Title:  video.Snippet.Title,
+			Status: video.Snippet.LiveBroadcastContent,
+		}
+		if video.Snippet.Thumbnails != nil {
+			broadcast.Thumbnail = proxyThumbnails(l.proxyURL, video.Id, *video.Snippet.Thumbnails)
+		}
+		if details := video.LiveStreamingDetails; details != nil {
+			broadcast.ScheduledStart = details.ScheduledStartTime
+			broadcast.ActualStart = details.ActualStartTime
+			broadcast.ConcurrentViewers = details.ConcurrentViewers
+		}
+		broadcasts = append(broadcasts, broadcast)
+	}
+	sortBroadcasts(broadcasts)
+	return broadcasts, nil
+}
+
+// isBroadcast reports whether video is a live or upcoming broadcast
+func isBroadcast(video *youtube.Video) bool {
+	if video.Snippet == nil {
+		return false
+	}
+	status := video.Snippet.LiveBroadcastContent
+	return status == broadcastLive || status == broadcastUpcoming
+}
+
+// sortBroadcasts puts live broadcasts first, upcoming ones follow in order of scheduled start
+func sortBroadcasts(broadcasts []LiveBroadcast) {
+	sort.SliceStable(broadcasts, func(i, j int) bool {
+		a, b := broadcasts[i], broadcasts[j]
+		if a.Status != b.Status {
+			return a.Status == broadcastLive
+		}
+		return a.ScheduledStart < b.ScheduledStart
+	})
+}
+
+// broadcastNear reports whether any broadcast is live or is scheduled within liveNearWindow from now.
+// Upcoming broadcasts long overdue are abandoned and do not count
+func broadcastNear(broadcasts []LiveBroadcast, now time.Time) bool {
+	for _, broadcast := range broadcasts {
+		if broadcast.Status == broadcastLive {
+			return true
+		}
+		start, err := time.Parse(time.RFC3339, broadcast.ScheduledStart)
+		if d := start.Sub(now); err == nil && d > -liveNearWindow && d < liveNearWindow {
+			return true
+		}
+	}
+	return false
+}
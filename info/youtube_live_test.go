@@ -0,0 +1,46 @@
+package info
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSortBroadcasts(t *testing.T) {
+	broadcasts := []LiveBroadcast{
+		{ID: "late", Status: broadcastUpcoming, ScheduledStart: "2024-05-02T10:00:00Z"},
+		{ID: "soon", Status: broadcastUpcoming, ScheduledStart: "2024-05-01T10:00:00Z"},
+		{ID: "now", Status: broadcastLive, ScheduledStart: "2024-04-30T10:00:00Z"},
+	}
+	sortBroadcasts(broadcasts)
+	for i, id := range []string{"now", "soon", "late"} {
+		if broadcasts[i].ID != id {
+			t.Errorf("expected %s at %d, got %s", id, i, broadcasts[i].ID)
+		}
+	}
+}
+
+func TestBroadcastNear(t *testing.T) {
+	now := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	upcoming := func(start string) []LiveBroadcast {
+		return []LiveBroadcast{{Status: broadcastUpcoming, ScheduledStart: start}}
+	}
+
+	if !broadcastNear(upcoming("2024-05-01T09:30:00Z"), now) {
+		t.Error("broadcast starting in 30 minutes should be near")
+	}
+	if broadcastNear(upcoming("2024-05-01T12:00:00Z"), now) {
+		t.Error("broadcast starting in 3 hours should not be near")
+	}
+	if !broadcastNear(upcoming("2024-05-01T08:50:00Z"), now) {
+		t.Error("broadcast late by 10 minutes should be near")
+	}
+	if broadcastNear(upcoming("2024-04-30T09:00:00Z"), now) {
+		t.Error("broadcast overdue by a day should not be near")
+	}
+	if !broadcastNear([]LiveBroadcast{{Status: broadcastLive}}, now) {
+		t.Error("live broadcast should be near")
+	}
+	if broadcastNear(nil, now) {
+		t.Error("no broadcasts should not be near")
+	}
+}
@@ -0,0 +1,72 @@
+package info
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// quotaCostList is a cost of YouTube Data API list call in quota units
+const quotaCostList = 1
+
+// ErrQuotaExhausted is returned when daily YouTube quota budget is spent
+var ErrQuotaExhausted = errors.New("youtube quota budget exhausted")
+
+// YoutubeQuota accounts YouTube Data API units spent during the quota day.
+// The quota resets at midnight Pacific Time, calls are refused once usage
+// reaches the daily limit minus reserve
+type YoutubeQuota struct {
+	mu      sync.Mutex
+	limit   int
+	reserve int
+	used    int
+	resetAt time.Time
+
+	now func() time.Time
+	loc *time.Location
+}
+
+// NewYoutubeQuota creates quota accounting with daily limit of units and reserve kept untouched
+func NewYoutubeQuota(limit, reserve int) *YoutubeQuota {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		loc = time.FixedZone("PST", -8*60*60)
+	}
+	return &YoutubeQuota{limit: limit, reserve: reserve, now: time.Now, loc: loc}
+}
+
+// Take spends units if the budget allows it
+func (q *YoutubeQuota) Take(units int) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.reset()
+	if q.used+units > q.limit-q.reserve {
+		log.Warnf("Youtube quota budget exhausted: %d of %d units used", q.used, q.limit)
+		return false
+	}
+	q.used += units
+	return true
+}
+
+// Used returns units spent during the current quota day
+func (q *YoutubeQuota) Used() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.reset()
+	return q.used
+}
+
+// reset starts new quota day once the previous one is over
+func (q *YoutubeQuota) reset() {
+	now := q.now().In(q.loc)
+	if now.Before(q.resetAt) {
+		return
+	}
+	y, m, d := now.Date()
+	q.resetAt = time.Date(y, m, d+1, 0, 0, 0, 0, q.loc)
+	q.used = 0
+}
@@ -0,0 +1,38 @@
+package info
+
+import (
+	"testing"
+	"time"
+)
+
+func TestYoutubeQuota(t *testing.T) {
+	q := NewYoutubeQuota(10, 3)
+	now := time.Date(2024, 3, 1, 23, 30, 0, 0, q.loc)
+	q.now = func() time.Time { return now }
+
+	for i := 0; i < 7; i++ {
+		if !q.Take(1) {
+			t.Fatalf("unit %d must be allowed", i+1)
+		}
+	}
+	if q.Take(1) {
+		t.Error("reserve must not be spent")
+	}
+	if q.Used() != 7 {
+		t.Errorf("expected 7 units used, got %d", q.Used())
+	}
+
+	// next day in UTC is still the same quota day
+	now = time.Date(2024, 3, 2, 7, 50, 0, 0, time.UTC)
+	if q.Take(1) {
+		t.Error("quota must not reset before midnight Pacific Time")
+	}
+
+	now = time.Date(2024, 3, 2, 0, 0, 0, 0, q.loc)
+	if q.Used() != 0 {
+		t.Errorf("quota must reset at midnight Pacific Time, %d units used", q.Used())
+	}
+	if !q.Take(7) || q.Take(1) {
+		t.Error("budget of the new day must be limit minus reserve")
+	}
+}
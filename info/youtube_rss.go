@@ -0,0 +1,130 @@
+package info
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/api/youtube/v3"
+)
+
+const (
+	youtubeRSSURL     = "https://www.youtube.com/feeds/videos.xml"
+	youtubeRSSTimeout = 10 * time.Second
+)
+
+// YoutubeRSS reads videos from public Atom feeds of channels and playlists.
+// It requires no API key but returns only 15 latest entries and no durations
+type YoutubeRSS struct {
+	baseURL string
+	client  *http.Client
+	// live provides broadcasts to leave out of the feeds, entries carry no broadcast status
+	live *YoutubeLive
+}
+
+type rssFeed struct {
+	Entries []rssEntry `xml:"http://www.w3.org/2005/Atom entry"`
+}
+
+type rssEntry struct {
+	VideoID   string `xml:"http://www.youtube.com/xml/schemas/2015 videoId"`
+	Title     string `xml:"http://www.w3.org/2005/Atom title"`
+	Published string `xml:"http://www.w3.org/2005/Atom published"`
+	Media     struct {
+		Thumbnail struct {
+			URL    string `xml:"url,attr"`
+			Width  int64  `xml:"width,attr"`
+			Height int64  `xml:"height,attr"`
+		} `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+		Description string `xml:"http://search.yahoo.com/mrss/ description"`
+		Community   struct {
+			Statistics struct {
+				Views string `xml:"views,attr"`
+			} `xml:"http://search.yahoo.com/mrss/ statistics"`
+		} `xml:"http://search.yahoo.com/mrss/ community"`
+	} `xml:"http://search.yahoo.com/mrss/ group"`
+}
+
+// NewYoutubeRSS creates reader of YouTube Atom feeds
+func NewYoutubeRSS() *YoutubeRSS {
+	return &YoutubeRSS{
+		baseURL: youtubeRSSURL,
+		client:  &http.Client{Timeout: youtubeRSSTimeout},
+	}
+}
+
+// ExcludeBroadcasts leaves current live and upcoming broadcasts out of the feeds.
+// It must be called before the reader is shared by the buffers
+func (r *YoutubeRSS) ExcludeBroadcasts(live *YoutubeLive) {
+	r.live = live
+}
+
+// Videos returns up to size latest videos of the feed
+func (r *YoutubeRSS) Videos(feed YoutubeFeed, size int) ([]VideoInfo, error) {
+	params := url.Values{}
+	if feed.PlaylistID != "" {
+		params.Set("playlist_id", feed.PlaylistID)
+	} else {
+		params.Set("channel_id", feed.ChannelID)
+	}
+
+	rs, err := r.client.Get(r.baseURL + "?" + params.Encode())
+	if err != nil {
+		return nil, errors.Wrap(err, "Cannot read Youtube RSS feed")
+	}
+	defer rs.Body.Close()
+	if rs.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("Youtube RSS feed of %s returned status %d", feed.Name, rs.StatusCode)
+	}
+
+	var atom rssFeed
+	if err := xml.NewDecoder(rs.Body).Decode(&atom); err != nil {
+		return nil, errors.Wrap(err, "Cannot parse Youtube RSS feed")
+	}
+
+	broadcasts := map[string]bool{}
+	if r.live != nil {
+		for _, broadcast := range r.live.GetBroadcasts() {
+			broadcasts[broadcast.ID] = true
+		}
+	}
+	videos := make([]VideoInfo, 0, min(len(atom.Entries), size))
+	for _, entry := range atom.Entries {
+		if len(videos) == size {
+			break
+		}
+		// live and upcoming broadcasts are served by YoutubeLive
+		if broadcasts[entry.VideoID] {
+			continue
+		}
+		videos = append(videos, entry.videoInfo())
+	}
+	return videos, nil
+}
+
+func (e *rssEntry) videoInfo() VideoInfo {
+	video := VideoInfo{
+		ID:          e.VideoID,
+		Title:       e.Title,
+		PublishedAt: e.Published,
+		Description: excerpt(e.Media.Description),
+	}
+	// align with RFC 3339 timestamps of the Data API
+	if published, err := time.Parse(time.RFC3339, e.Published); err == nil {
+		video.PublishedAt = published.UTC().Format(time.RFC3339)
+	}
+	if thumb := e.Media.Thumbnail; thumb.URL != "" {
+		video.Thumbnail = youtube.ThumbnailDetails{High: &youtube.Thumbnail{
+			Url:    thumb.URL,
+			Width:  thumb.Width,
+			Height: thumb.Height,
+		}}
+	}
+	if views, err := strconv.ParseUint(e.Media.Community.Statistics.Views, 10, 64); err == nil {
+		video.Statistics.ViewCount = views
+	}
+	return video
+}
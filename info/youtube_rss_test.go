@@ -0,0 +1,88 @@
+package info
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/reportportal/landing-aggregator/pkg/snapshot"
+)
+
+const rssSample = `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns:yt="http://www.youtube.com/xml/schemas/2015" xmlns:media="http://search.yahoo.com/mrss/" xmlns="http://www.w3.org/2005/Atom">
+ <title>ReportPortal</title>
+ <entry>
+  <id>yt:video:abc</id>
+  <yt:videoId>abc</yt:videoId>
+  <title>First</title>
+  <published>2024-05-01T10:00:00+02:00</published>
+  <media:group>
+   <media:thumbnail url="https://i1.ytimg.com/vi/abc/hqdefault.jpg" width="480" height="360"/>
+   <media:community>
+    <media:statistics views="1234"/>
+   </media:community>
+  </media:group>
+ </entry>
+ <entry>
+  <yt:videoId>def</yt:videoId>
+  <title>Second</title>
+  <published>2024-04-01T10:00:00+00:00</published>
+ </entry>
+</feed>`
+
+func TestYoutubeRSSVideos(t *testing.T) {
+	var query string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, rq *http.Request) {
+		query = rq.URL.RawQuery
+		_, _ = w.Write([]byte(rssSample))
+	}))
+	defer srv.Close()
+
+	rss := NewYoutubeRSS()
+	rss.baseURL = srv.URL
+
+	videos, err := rss.Videos(YoutubeFeed{Name: "default", ChannelID: "UC1"}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != "channel_id=UC1" {
+		t.Errorf("unexpected query %q", query)
+	}
+	if len(videos) != 1 {
+		t.Fatalf("expected 1 video, got %d", len(videos))
+	}
+	v := videos[0]
+	if v.ID != "abc" || v.Title != "First" {
+		t.Errorf("unexpected video %+v", v)
+	}
+	if v.PublishedAt != "2024-05-01T08:00:00Z" {
+		t.Errorf("unexpected publish date %q", v.PublishedAt)
+	}
+	if v.Thumbnail.High == nil || v.Thumbnail.High.Width != 480 {
+		t.Errorf("unexpected thumbnail %+v", v.Thumbnail)
+	}
+	if v.Statistics.ViewCount != 1234 {
+		t.Errorf("unexpected view count %d", v.Statistics.ViewCount)
+	}
+}
+
+func TestYoutubeRSSExcludesBroadcasts(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, rq *http.Request) {
+		_, _ = w.Write([]byte(rssSample))
+	}))
+	defer srv.Close()
+
+	rss := NewYoutubeRSS()
+	rss.baseURL = srv.URL
+	rss.ExcludeBroadcasts(&YoutubeLive{info: snapshot.NewStore(liveSyncPeriod, []LiveBroadcast{
+		{ID: "abc", Status: broadcastUpcoming},
+	})})
+
+	videos, err := rss.Videos(YoutubeFeed{Name: "default", ChannelID: "UC1"}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(videos) != 1 || videos[0].ID != "def" {
+		t.Errorf("expected upcoming broadcast to be excluded, got %+v", videos)
+	}
+}
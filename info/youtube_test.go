@@ -0,0 +1,157 @@
+package info
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/reportportal/landing-aggregator/buf"
+	"github.com/reportportal/landing-aggregator/pkg/snapshot"
+	"google.golang.org/api/option"
+	"google.golang.org/api/youtube/v3"
+)
+
+// fakeYoutubeAPI serves playlist items and videos of the Data API
+type fakeYoutubeAPI struct {
+	listStatus int
+	views      string
+	calls      []string
+}
+
+func (f *fakeYoutubeAPI) ServeHTTP(w http.ResponseWriter, rq *http.Request) {
+	f.calls = append(f.calls, rq.URL.Path+"?part="+rq.URL.Query().Get("part"))
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case strings.HasSuffix(rq.URL.Path, "/playlistItems"):
+		if f.listStatus != http.StatusOK {
+			w.WriteHeader(f.listStatus)
+			return
+		}
+		if rq.Header.Get("If-None-Match") == `"list"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_, _ = w.Write([]byte(`{"etag":"\"list\"","items":[{"contentDetails":{"videoId":"a"}},{"contentDetails":{"videoId":"b"}}]}`))
+	case strings.HasSuffix(rq.URL.Path, "/videos"):
+		if rq.URL.Query().Get("part") == "statistics" {
+			if rq.Header.Get("If-None-Match") == `"stats"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			_, _ = w.Write([]byte(`{"etag":"\"stats\"","items":[{"id":"a","statistics":{"viewCount":"` + f.views + `"}},{"id":"b","statistics":{"viewCount":"1"}}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"items":[
+			{"id":"a","snippet":{"title":"A","publishedAt":"2024-05-02T10:00:00Z"},"contentDetails":{"duration":"PT30S","caption":"true"},"statistics":{"viewCount":"10"}},
+			{"id":"b","snippet":{"title":"B","publishedAt":"2024-05-01T10:00:00Z"},"contentDetails":{"duration":"PT12M3S","caption":"false"},"statistics":{"viewCount":"1"}}
+		]}`))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newTestBuffer(t *testing.T, api http.Handler) *YoutubeBuffer {
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+	yt, err := youtube.NewService(context.Background(), option.WithEndpoint(srv.URL+"/"), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &YoutubeBuffer{
+		youtube:   yt,
+		quota:     NewYoutubeQuota(1000, 0),
+		feed:      YoutubeFeed{Name: "test", PlaylistID: "PL"},
+		cacheSize: 10,
+		videos: buf.New(10,
+			buf.WithTTL[VideoInfo](videoTTL),
+			buf.WithKey(func(v VideoInfo) string { return v.ID })),
+		info: snapshot.NewStore(videosStatsSyncPeriod, []VideoInfo{}),
+	}
+}
+
+func TestYoutubeBufferLoad(t *testing.T) {
+	api := &fakeYoutubeAPI{listStatus: http.StatusOK, views: "10"}
+	buffer := newTestBuffer(t, api)
+
+	buffer.loadVideos()
+	videos := buffer.GetAllVideos()
+	if len(videos) != 2 || videos[0].ID != "a" || videos[1].ID != "b" {
+		t.Fatalf("unexpected videos %+v", videos)
+	}
+	if videos[0].Short == nil || !*videos[0].Short || videos[0].Captions == nil || !*videos[0].Captions {
+		t.Errorf("unexpected details of the short %+v", videos[0])
+	}
+	if *videos[1].Short || videos[1].DurationText != "12:03" {
+		t.Errorf("unexpected details of the video %+v", videos[1])
+	}
+	version := buffer.Snapshot().Version
+
+	// not modified list keeps the videos without loading their details
+	api.calls = nil
+	buffer.loadVideos()
+	if len(api.calls) != 1 || buffer.Snapshot().Version != version || len(buffer.GetAllVideos()) != 2 {
+		t.Errorf("not modified list must keep the videos, calls %v", api.calls)
+	}
+
+	// statistics are updated without reloading the list
+	api.calls = nil
+	api.views = "42"
+	buffer.loadStatistics()
+	videos = buffer.GetAllVideos()
+	if len(api.calls) != 1 || api.calls[0] != "/youtube/v3/videos?part=statistics" {
+		t.Errorf("unexpected calls %v", api.calls)
+	}
+	if len(videos) != 2 || videos[0].Statistics.ViewCount != 42 || videos[0].Title != "A" || videos[1].ID != "b" {
+		t.Errorf("unexpected videos after statistics refresh %+v", videos)
+	}
+
+	// failed refresh keeps the cached videos
+	api.listStatus = http.StatusInternalServerError
+	buffer.listETag = ""
+	version = buffer.Snapshot().Version
+	buffer.loadVideos()
+	if buffer.Snapshot().Version != version || len(buffer.GetAllVideos()) != 2 {
+		t.Errorf("failed refresh must keep cached videos")
+	}
+}
+
+func TestYoutubeBufferNotModifiedRenewsTTL(t *testing.T) {
+	api := &fakeYoutubeAPI{listStatus: http.StatusOK, views: "10"}
+	buffer := newTestBuffer(t, api)
+	now := time.Now()
+	buffer.videos = buf.New(10,
+		buf.WithTTL[VideoInfo](videoTTL),
+		buf.WithKey(func(v VideoInfo) string { return v.ID }),
+		buf.WithClock[VideoInfo](func() time.Time { return now }))
+
+	buffer.loadVideos()
+	buffer.loadStatistics()
+	// the list does not change for two days
+	for elapsed := time.Duration(0); elapsed < 2*videoTTL; elapsed += videosListSyncPeriod {
+		now = now.Add(videosListSyncPeriod)
+		buffer.loadVideos()
+	}
+	if videos := buffer.videos.Snapshot(); len(videos) != 2 {
+		t.Fatalf("not modified list must keep the videos, got %+v", videos)
+	}
+
+	// the list cannot be loaded, while statistics do not change for two days
+	api.listStatus = http.StatusInternalServerError
+	buffer.listETag = ""
+	for elapsed := time.Duration(0); elapsed < 2*videoTTL; elapsed += videosStatsSyncPeriod {
+		now = now.Add(videosStatsSyncPeriod)
+		buffer.loadVideos()
+		buffer.loadStatistics()
+	}
+	if videos := buffer.videos.Snapshot(); len(videos) != 2 {
+		t.Fatalf("not modified statistics must keep the videos, got %+v", videos)
+	}
+	api.calls = nil
+	buffer.loadStatistics()
+	if len(api.calls) != 1 {
+		t.Errorf("statistics of the kept videos must be refreshed, calls %v", api.calls)
+	}
+}
@@ -1,11 +1,19 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/caarlos0/env/v6"
@@ -14,12 +22,54 @@ import (
 	"github.com/reportportal/commons-go/v5/commons"
 	"github.com/reportportal/commons-go/v5/server"
 	"github.com/reportportal/landing-aggregator/info"
+	"github.com/reportportal/landing-aggregator/pkg/audit"
 	"github.com/reportportal/landing-aggregator/pkg/captcha"
+	"github.com/reportportal/landing-aggregator/pkg/consent"
+	"github.com/reportportal/landing-aggregator/pkg/cors"
+	"github.com/reportportal/landing-aggregator/pkg/email"
+	"github.com/reportportal/landing-aggregator/pkg/events"
+	"github.com/reportportal/landing-aggregator/pkg/httpcache"
+	"github.com/reportportal/landing-aggregator/pkg/imgproxy"
+	"github.com/reportportal/landing-aggregator/pkg/newsletter"
+	"github.com/reportportal/landing-aggregator/pkg/openapi"
+	"github.com/reportportal/landing-aggregator/pkg/ratelimit"
+	"github.com/reportportal/landing-aggregator/pkg/realip"
+	"github.com/reportportal/landing-aggregator/pkg/review"
+	"github.com/reportportal/landing-aggregator/pkg/snapshot"
 	log "github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+	"google.golang.org/api/youtube/v3"
 )
 
 const (
 	defaultYoutubeRSCount = 3
+	defaultYoutubeFeed    = "default"
+	youtubeFeedLive       = "live"
+	maxWebhookBodySize    = 1 << 20
+	// subscription bodies carry a handful of short fields
+	maxSubscriptionBodySize = 64 << 10
+
+	// route names used by captcha actions allow-list
+	captchaRouteSubscriptions = "subscriptions"
+	captchaRouteMailchimp     = "mailchimp"
+	// seconds clients wait before retrying request failed by the captcha provider
+	captchaRetryAfter = "5"
+
+	rateLimitScopeIP    = "ip"
+	rateLimitScopeEmail = "email"
+	rateLimitScopeList  = "list"
+
+	// types of events pushed to /events subscribers
+	eventTypeRelease = "release"
+	eventTypeStars   = "stars"
+	eventTypeVideo   = "video"
+	eventTypeNews    = "news"
+	eventTypeLive    = "live"
+	eventsPollPeriod = time.Second
+
+	youtubeProviderAuto = "auto"
+	youtubeProviderAPI  = "api"
+	youtubeProviderRSS  = "rss"
 )
 
 var (
@@ -58,17 +108,118 @@ func main() {
 		Branch:    Branch,
 		BuildDate: BuildDate,
 	}
+	buildSnapshot := snapshot.Static(buildInfo)
 
 	cma := info.NewCma(conf.CmaSpaceID, conf.CmaToken, conf.CmaLimit)
 
-	var mailchimpClient *info.MailchimpClient
+	emailValidator := buildEmailValidator(conf)
+
+	captchaVerifier, err := captcha.NewVerifier(captcha.Config{
+		Provider:  conf.CaptchaProvider,
+		ProjectID: conf.GoogleProjectID,
+		SiteKey:   conf.captchaSiteKey(),
+		Secret:    conf.CaptchaSecret,
+		Timeout:   time.Duration(conf.CaptchaTimeout) * time.Second,
+		VerifyURL: conf.CaptchaVerifyURL,
+	})
+	if err != nil {
+		log.Error("Cannot init captcha verifier. ", err)
+	}
+	captchaPolicy := buildCaptchaPolicy(conf)
+
+	reviewQueue, err := buildReviewQueue(conf)
+	if err != nil {
+		log.Error("Cannot init captcha review queue. ", err)
+	}
+
+	captchaGuard, err := buildCaptchaGuard(conf, captchaVerifier, reviewQueue)
+	if err != nil {
+		log.Error("Cannot init captcha guard. ", err)
+	}
+
+	var mailchimpClient *newsletter.MailchimpClient
 
 	if conf.MailchimpAPIKey == "false" {
 		log.Error("Environment variable MAILCHIMP_API_KEY not set.")
 	} else {
-		mailchimpClient = info.NewMailchimpClient(conf.MailchimpAPIKey)
+		mailchimpClient = newsletter.NewMailchimpClient(conf.MailchimpAPIKey)
 		mailchimpClient.User = conf.MailchimpUser
 		mailchimpClient.Timeout = time.Duration(conf.MailchimpTimeout) * time.Second
+		mailchimpClient.Validator = emailValidator
+		mailchimpClient.MarketingPermissionIDs = conf.MailchimpMarketingPermissions
+		mailchimpClient.ConsentMergeField = conf.MailchimpConsentMergeField
+	}
+
+	subscriber, err := buildSubscriber(conf, mailchimpClient)
+	if err != nil {
+		log.Error("Cannot init newsletter subscriber. ", err)
+	}
+
+	consentStore, err := buildConsentStore(conf)
+	if err != nil {
+		log.Error("Cannot init consent store. ", err)
+	}
+
+	mailchimpWebhook, err := buildMailchimpWebhook(conf)
+	if err != nil {
+		log.Error("Cannot init Mailchimp webhook. ", err)
+	}
+
+	// subscribeMailchimp adds member to the Mailchimp list and records consent
+	subscribeMailchimp := func(ctx context.Context, body io.Reader, listID string, c *newsletter.Consent) (*newsletter.MailchimpMember, error) {
+		rqBody, err := io.ReadAll(body)
+		if err != nil {
+			return nil, newsletter.NewError(newsletter.CodeInvalidRequest, "failed to read request body")
+		}
+		// Mailchimp member request has no consent field, so the version is read next to the member fields
+		var provided struct {
+			ConsentTextVersion string `json:"consent_text_version"`
+		}
+		_ = json.Unmarshal(rqBody, &provided)
+		if c.TextVersion, err = consentTextVersion(conf, provided.ConsentTextVersion); err != nil {
+			return nil, err
+		}
+		member, err := mailchimpClient.AddSubscription(ctx, bytes.NewReader(rqBody), listID, c)
+		if err != nil {
+			return nil, err
+		}
+		recordConsent(consentStore, mailchimpClient.Provider(), listID, member.EmailAddress, member.Status, c)
+		return member, nil
+	}
+
+	// subscribeNewsletter subscribes through the configured provider and records consent
+	subscribeNewsletter := func(ctx context.Context, body io.Reader, c *newsletter.Consent) (*newsletter.Subscription, error) {
+		subscriptionRQ, err := newsletter.ParseSubscriptionRequest(ctx, body, emailValidator)
+		if err != nil {
+			return nil, err
+		}
+		if c.TextVersion, err = consentTextVersion(conf, subscriptionRQ.ConsentTextVersion); err != nil {
+			return nil, err
+		}
+		subscriptionRQ.ConsentTextVersion = c.TextVersion
+		subscriptionRQ.Consent = c
+		listID := subscriptionRQ.ListID
+		if listID == "" {
+			listID = conf.NewsletterListID
+		}
+		if listID == "" {
+			return nil, newsletter.NewError(newsletter.CodeInvalidRequest, "list id is required")
+		}
+
+		subscription, err := subscriber.Subscribe(ctx, listID, subscriptionRQ)
+		if err != nil {
+			return nil, err
+		}
+		recordConsent(consentStore, subscription.Provider, listID, subscription.EmailAddress, string(subscription.Status), c)
+		return subscription, nil
+	}
+
+	subscribeLimiter := buildSubscribeLimiter(conf)
+
+	if annotator, ok := captchaVerifier.(captcha.Annotator); ok && conf.CaptchaAnnotations && mailchimpWebhook != nil && consentStore != nil {
+		mailchimpWebhook.OnEvent = func(event *newsletter.MailchimpEvent) {
+			annotateSubscription(annotator, consentStore, event)
+		}
 	}
 
 	var ghAggregator *info.GitHubAggregator
@@ -78,15 +229,13 @@ func main() {
 		ghAggregator = info.NewGitHubAggregator(conf.GitHubToken, conf.IncludeBeta)
 	}
 
-	var youtubeBuffer *info.YoutubeBuffer
-	var err error
-	if conf.YoutubeChannelID == "" {
+	youtubeFeeds, youtubeLive, err := buildYoutubeFeeds(conf)
+	if err != nil {
+		log.Error("Cannot init youtube buffer. ", err)
+	}
+	youtubeBuffer := youtubeFeeds[defaultYoutubeFeed]
+	if youtubeBuffer == nil {
 		log.Error("Environment variable YOUTUBE_CHANNEL_ID not set")
-	} else {
-		youtubeBuffer, err = buildYoutubeBuffer(conf)
-		if err != nil {
-			log.Error("Cannot init youtube buffer. ", err)
-		}
 	}
 
 	router := chi.NewMux()
@@ -94,8 +243,25 @@ func main() {
 	//404 - NOT Found middleware
 	router.NotFound(notFoundMiddleware)
 
-	//CORS middleware, allow all domains
-	router.Use(enableCORSMiddleware)
+	//CORS middleware, answers preflight requests of all routes
+	corsHandler, err := buildCORS(conf, router)
+	if err != nil {
+		log.Fatal("Invalid CORS configuration. ", err)
+	}
+	router.Use(corsHandler.Handler)
+
+	// resolve client address behind trusted reverse proxies
+	ipResolver, err := realip.NewResolver(conf.TrustedProxies)
+	if err != nil {
+		log.Fatal(err)
+	}
+	router.Use(ipResolver.Middleware)
+
+	// rejects requests not matching the OpenAPI description of the route
+	api := buildOpenAPI(conf)
+	router.Use(api.Validator(router, func(w http.ResponseWriter, rq *http.Request, err *openapi.ValidationError) {
+		jsonpRS(http.StatusBadRequest, map[string]string{"error": err.Error(), "code": string(newsletter.CodeInvalidRequest)}, w, rq)
+	}))
 
 	//info endpoint
 	router.Get("/info", func(w http.ResponseWriter, rq *http.Request) {
@@ -108,75 +274,138 @@ func main() {
 			jsonpRS(http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("provided count exceed max allower value (%d)", conf.CmaLimit)}, w, rq)
 			return
 		}
-		jsonpRS(http.StatusOK, info.GetTwitterFeed(cma, count), w, rq)
+		feed := cma.FeedSnapshot()
+		if httpcache.Check(w, rq, feed) {
+			return
+		}
+		jsonpRS(http.StatusOK, info.GetTwitterFeed(feed, count), w, rq)
 	})
 
 	router.Get("/youtube", func(w http.ResponseWriter, rq *http.Request) {
-		count := getQueryIntParam(rq, "count", defaultYoutubeRSCount)
-		if count > conf.YoutubeBufferSize {
-			jsonpRS(http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("provided count exceed max allower value (%d)", conf.YoutubeBufferSize)}, w, rq)
+		videosRS(youtubeBuffer, w, rq)
+	})
+
+	router.Get("/youtube/live", func(w http.ResponseWriter, rq *http.Request) {
+		if youtubeLive == nil {
+			jsonpRS(http.StatusServiceUnavailable, map[string]string{"error": "youtube live broadcasts not initialized"}, w, rq)
+			return
+		}
+		if httpcache.Check(w, rq, youtubeLive.Snapshot()) {
 			return
 		}
-		jsonpRS(http.StatusOK, youtubeBuffer.GetVideos(count), w, rq)
+		jsonpRS(http.StatusOK, youtubeLive.GetBroadcasts(), w, rq)
 	})
 
+	router.Get("/youtube/{feed}", func(w http.ResponseWriter, rq *http.Request) {
+		feed, ok := youtubeFeeds[chi.URLParam(rq, "feed")]
+		if !ok {
+			jsonpRS(http.StatusNotFound, map[string]string{"error": "unknown youtube feed"}, w, rq)
+			return
+		}
+		videosRS(feed, w, rq)
+	})
+
+	imageProxy, err := buildImageProxy(conf)
+	if err != nil {
+		log.Error("Cannot init image proxy. ", err)
+	}
+	if imageProxy != nil {
+		router.Get("/images/youtube/{id}/{size}", func(w http.ResponseWriter, rq *http.Request) {
+			upstream, ok := imgproxy.YoutubeURL(chi.URLParam(rq, "id"), chi.URLParam(rq, "size"))
+			if !ok {
+				jsonRS(http.StatusNotFound, map[string]string{"error": "unknown youtube thumbnail"}, w)
+				return
+			}
+			imageProxy.Serve(w, rq, upstream)
+		})
+
+		router.Get("/images/contentful/*", func(w http.ResponseWriter, rq *http.Request) {
+			upstream, ok := imgproxy.ContentfulURL(conf.CmaSpaceID, chi.URLParam(rq, "*"), rq.URL.Query())
+			if !ok {
+				jsonRS(http.StatusNotFound, map[string]string{"error": "unknown contentful asset or image parameters"}, w)
+				return
+			}
+			imageProxy.Serve(w, rq, upstream)
+		})
+	}
+
 	router.Get("/versions", func(w http.ResponseWriter, rq *http.Request) {
+		if httpcache.Check(w, rq, ghAggregator.LatestTagsSnapshot()) {
+			return
+		}
 		jsonpRS(http.StatusOK, ghAggregator.GetLatestTags(), w, rq)
 	})
 
 	//GitHub-related routes
 	router.Route("/github/", func(ghRouter chi.Router) {
 		ghRouter.Get("/stars", func(w http.ResponseWriter, rq *http.Request) {
+			if httpcache.Check(w, rq, ghAggregator.StarsSnapshot()) {
+				return
+			}
 			jsonRS(http.StatusOK, ghAggregator.GetStars(), w)
 		})
 		ghRouter.Get("/contribution", func(w http.ResponseWriter, rq *http.Request) {
+			if httpcache.Check(w, rq, ghAggregator.ContributionSnapshot()) {
+				return
+			}
 			jsonRS(http.StatusOK, ghAggregator.GetContributionStats(), w)
 		})
 		ghRouter.Get("/issues", func(w http.ResponseWriter, rq *http.Request) {
+			if httpcache.Check(w, rq, ghAggregator.IssueStatsSnapshot()) {
+				return
+			}
 			jsonRS(http.StatusOK, ghAggregator.GetIssueStats(), w)
 		})
 	})
 
 	// aggregate everything into on rs
+	aggregate := buildAggregate(conf, buildSnapshot, cma, youtubeBuffer, ghAggregator)
 	router.Get("/", func(w http.ResponseWriter, rq *http.Request) {
-		rs := map[string]interface{}{}
-
-		rs["build"] = buildInfo
-		rs["tweets"] = info.GetTwitterFeed(cma, conf.CmaLimit)
-		rs["youtube"] = youtubeBuffer.GetVideos(defaultYoutubeRSCount)
-
-		ghStats := map[string]interface{}{
-			"stars":              ghAggregator.GetStars(),
-			"contribution_stats": ghAggregator.GetContributionStats(),
-			"issue_stats":        ghAggregator.GetIssueStats(),
+		query, err := aggregate.ParseQuery(rq.URL.Query())
+		if err != nil {
+			jsonpRS(http.StatusBadRequest, map[string]string{"error": err.Error()}, w, rq)
+			return
+		}
+		rendered, err := aggregate.Render(query)
+		if err != nil {
+			log.Errorf("Cannot render aggregated response: %v", err)
+			jsonpRS(http.StatusInternalServerError, map[string]string{"error": "cannot render response"}, w, rq)
+			return
+		}
+		if httpcache.Check(w, rq, rendered.Snapshots...) {
+			return
 		}
-		rs["github"] = ghStats
-		rs["latest_versions"] = ghAggregator.GetLatestTags()
 
-		jsonRS(http.StatusOK, rs, w)
+		if _, ok := rq.URL.Query()["jsonp"]; ok {
+			_, body := rendered.Body.Negotiate("")
+			jsonpRS(http.StatusOK, json.RawMessage(bytes.TrimSpace(body)), w, rq)
+			return
+		}
+		if err = rendered.Body.Write(w, rq, http.StatusOK, "application/json; charset=utf-8"); err != nil {
+			log.Error("Cannot respond", err)
+		}
 	})
 
 	// Mailchimp-related routes
 	router.Route("/mailchimp/", func(mcRouter chi.Router) {
 		mcRouter.Route("/lists/{listID}/members", func(mcListRouter chi.Router) {
-			mcListRouter.Options("/", func(w http.ResponseWriter, rq *http.Request) {
-				w.Header().Add("Access-Control-Allow-Methods", "OPTIONS, POST")
-				w.Header().Add("Access-Control-Allow-Headers", "Content-Type, RP-Recaptcha-Token, RP-Recaptcha-Action")
-				w.Header().Add("Access-Control-Max-Age", "86400")
-				w.WriteHeader(http.StatusOK)
-			})
 			mcListRouter.Post("/", func(w http.ResponseWriter, rq *http.Request) {
 				if !checkMailchimpClient(mailchimpClient, w) {
 					return
 				}
 
-				if !checkCaptchaAssessment(conf, rq, w) {
+				listID := chi.URLParam(rq, "listID")
+				if !checkSubscribeRateLimits(subscribeLimiter, listID, rq, w) {
+					return
+				}
+				captchaResult, ok := checkCaptchaAssessment(captchaGuard, captchaPolicy, reviewQueue, captchaRouteMailchimp, listID, rq, w)
+				if !ok {
 					return
 				}
 
-				member, err := mailchimpClient.AddSubscription(rq.Body, chi.URLParam(rq, "listID"))
+				member, err := subscribeMailchimp(rq.Context(), rq.Body, listID, buildConsent(rq, captchaResult))
 				if err != nil {
-					jsonRS(http.StatusBadRequest, map[string]string{"error": err.Error()}, w)
+					subscriptionErrorRS(err, w)
 					return
 				}
 				jsonRS(http.StatusOK, member, w)
@@ -184,6 +413,166 @@ func main() {
 		})
 	})
 
+	// Provider-neutral subscription routes
+	router.Route("/subscriptions", func(subRouter chi.Router) {
+		subRouter.Post("/", func(w http.ResponseWriter, rq *http.Request) {
+			if !checkSubscriber(subscriber, w) {
+				return
+			}
+
+			if !checkSubscribeRateLimits(subscribeLimiter, conf.NewsletterListID, rq, w) {
+				return
+			}
+			captchaResult, ok := checkCaptchaAssessment(captchaGuard, captchaPolicy, reviewQueue, captchaRouteSubscriptions, "", rq, w)
+			if !ok {
+				return
+			}
+
+			subscription, err := subscribeNewsletter(rq.Context(), rq.Body, buildConsent(rq, captchaResult))
+			if err != nil {
+				subscriptionErrorRS(err, w)
+				return
+			}
+			jsonRS(http.StatusOK, subscription, w)
+		})
+	})
+
+	// Inbound webhooks of newsletter providers
+	router.Route("/webhooks", func(whRouter chi.Router) {
+		// counters reveal configured profiles, so they are available to admins only
+		whRouter.With(adminAuthMiddleware(conf.AdminToken)).Get("/stats", func(w http.ResponseWriter, rq *http.Request) {
+			if mailchimpWebhook == nil {
+				jsonRS(http.StatusOK, &newsletter.WebhookStats{}, w)
+				return
+			}
+			jsonRS(http.StatusOK, mailchimpWebhook.Stats(), w)
+		})
+		whRouter.Route("/mailchimp/{profile}", func(mcRouter chi.Router) {
+			// Mailchimp checks the URL with a GET request when webhook is being created
+			mcRouter.Get("/", func(w http.ResponseWriter, rq *http.Request) {
+				if !checkMailchimpWebhook(mailchimpWebhook, w, rq) {
+					return
+				}
+				jsonRS(http.StatusOK, map[string]string{"status": "ok"}, w)
+			})
+			mcRouter.Post("/", func(w http.ResponseWriter, rq *http.Request) {
+				if !checkMailchimpWebhook(mailchimpWebhook, w, rq) {
+					return
+				}
+
+				rq.Body = http.MaxBytesReader(w, rq.Body, maxWebhookBodySize)
+				if err := rq.ParseForm(); err != nil {
+					jsonRS(http.StatusBadRequest, map[string]string{"error": "invalid form body"}, w)
+					return
+				}
+
+				if _, err := mailchimpWebhook.Receive(chi.URLParam(rq, "profile"), rq.PostForm); err != nil {
+					jsonRS(http.StatusBadRequest, map[string]string{"error": err.Error()}, w)
+					return
+				}
+				jsonRS(http.StatusOK, map[string]string{"status": "ok"}, w)
+			})
+		})
+	})
+
+	// Administrative routes, available only if ADMIN_TOKEN is set
+	router.Route("/admin", func(adminRouter chi.Router) {
+		adminRouter.Use(adminAuthMiddleware(conf.AdminToken))
+
+		adminRouter.Route("/consents", func(consentRouter chi.Router) {
+			// export all consent records of the email address
+			consentRouter.Get("/", func(w http.ResponseWriter, rq *http.Request) {
+				address, ok := checkConsentRequest(consentStore, w, rq)
+				if !ok {
+					return
+				}
+				records, err := consentStore.Find(address)
+				if err != nil {
+					log.Errorf("Cannot read consent records: %v", err)
+					jsonRS(http.StatusInternalServerError, map[string]string{"error": "cannot read consent records"}, w)
+					return
+				}
+				jsonRS(http.StatusOK, records, w)
+			})
+			// erase all consent records of the email address
+			consentRouter.Delete("/", func(w http.ResponseWriter, rq *http.Request) {
+				address, ok := checkConsentRequest(consentStore, w, rq)
+				if !ok {
+					return
+				}
+				erased, err := eraseAddress(address, consentStore, mailchimpWebhook, reviewQueue)
+				if err != nil {
+					log.Errorf("Cannot erase records: %v", err)
+					jsonRS(http.StatusInternalServerError, map[string]string{"error": "cannot erase records"}, w)
+					return
+				}
+				jsonRS(http.StatusOK, erased, w)
+			})
+		})
+
+		// subscriptions deferred while captcha provider was unavailable
+		adminRouter.Route("/reviews", func(reviewRouter chi.Router) {
+			reviewRouter.Get("/", func(w http.ResponseWriter, rq *http.Request) {
+				if !checkReviewQueue(reviewQueue, w) {
+					return
+				}
+				items, err := reviewQueue.Pending()
+				if err != nil {
+					log.Errorf("Cannot read review queue: %v", err)
+					jsonRS(http.StatusInternalServerError, map[string]string{"error": "cannot read review queue"}, w)
+					return
+				}
+				jsonRS(http.StatusOK, items, w)
+			})
+			// replay the deferred subscription without captcha verification
+			reviewRouter.Post("/{id}/approve", func(w http.ResponseWriter, rq *http.Request) {
+				item, ok := checkReviewItem(reviewQueue, w, rq)
+				if !ok {
+					return
+				}
+
+				c := &newsletter.Consent{IP: item.IP, UserAgent: item.UserAgent, Timestamp: item.Received}
+				var rs interface{}
+				var err error
+				switch item.Route {
+				case captchaRouteMailchimp:
+					if !checkMailchimpClient(mailchimpClient, w) {
+						return
+					}
+					rs, err = subscribeMailchimp(rq.Context(), strings.NewReader(item.Body), item.ListID, c)
+				case captchaRouteSubscriptions:
+					if !checkSubscriber(subscriber, w) {
+						return
+					}
+					rs, err = subscribeNewsletter(rq.Context(), strings.NewReader(item.Body), c)
+				default:
+					err = newsletter.NewError(newsletter.CodeInvalidRequest, fmt.Sprintf("unknown review route %q", item.Route))
+				}
+				if err != nil {
+					subscriptionErrorRS(err, w)
+					return
+				}
+
+				resolveReviewItem(reviewQueue, item.ID, review.StatusApproved)
+				jsonRS(http.StatusOK, rs, w)
+			})
+			reviewRouter.Post("/{id}/reject", func(w http.ResponseWriter, rq *http.Request) {
+				item, ok := checkReviewItem(reviewQueue, w, rq)
+				if !ok {
+					return
+				}
+				resolveReviewItem(reviewQueue, item.ID, review.StatusRejected)
+				jsonRS(http.StatusOK, map[string]string{"status": review.StatusRejected}, w)
+			})
+		})
+	})
+
+	// pushes updates of the sources instead of polling
+	router.Get("/events", buildEvents(conf, cma, ghAggregator, youtubeBuffer, youtubeLive).ServeHTTP)
+
+	// OpenAPI document of all the routes above
+	router.Get("/openapi.json", api.Handler(router))
+
 	// listen and server on mentioned port
 	log.Infof("Starting on port %d", conf.Port)
 
@@ -222,7 +611,8 @@ func loadConfig() *config {
 	return &cfg
 }
 
-func buildYoutubeBuffer(conf *config) (buf *info.YoutubeBuffer, err error) {
+func buildYoutubeFeeds(conf *config) (feeds map[string]*info.YoutubeBuffer, live *info.YoutubeLive, err error) {
+	feeds = map[string]*info.YoutubeBuffer{}
 	defer func() {
 		if r := recover(); r != nil {
 			// find out exactly what the error was and set err
@@ -235,19 +625,463 @@ func buildYoutubeBuffer(conf *config) (buf *info.YoutubeBuffer, err error) {
 				err = errors.New("unknown panic")
 			}
 			// invalidate rep
-			buf = nil
+			feeds = map[string]*info.YoutubeBuffer{}
+			live = nil
 			// return the modified err and rep
 		}
 	}()
 
-	if conf.GoogleAPIKeyFile == "" {
-		return nil, errors.New("environment variable GOOGLE_API_KEY not set")
+	var descriptions []info.YoutubeFeed
+	if conf.YoutubeChannelID != "" && conf.YoutubeChannelID != "false" {
+		descriptions = append(descriptions, info.YoutubeFeed{
+			Name:      defaultYoutubeFeed,
+			ChannelID: conf.YoutubeChannelID,
+			Size:      conf.YoutubeBufferSize,
+		})
+	}
+	for name, source := range parseKeyValues(conf.YoutubeFeeds) {
+		if name == youtubeFeedLive || name == defaultYoutubeFeed {
+			return feeds, nil, fmt.Errorf("youtube feed name %q is reserved", name)
+		}
+		feed, err := parseYoutubeFeed(name, source, conf.YoutubeBufferSize)
+		if err != nil {
+			return feeds, nil, err
+		}
+		descriptions = append(descriptions, feed)
+	}
+	if len(descriptions) == 0 {
+		return feeds, nil, nil
+	}
+
+	var srv *youtube.Service
+	var rss *info.YoutubeRSS
+	apiKeySet := conf.GoogleAPIKeyFile != "" && conf.GoogleAPIKeyFile != "false"
+	switch conf.YoutubeProvider {
+	case youtubeProviderAuto:
+		if !apiKeySet {
+			log.Warn("Environment variable GOOGLE_API_KEY not set. Youtube videos are read from RSS feeds")
+		}
+		rss = info.NewYoutubeRSS()
+	case youtubeProviderAPI:
+		if !apiKeySet {
+			return feeds, nil, errors.New("environment variable GOOGLE_API_KEY not set")
+		}
+	case youtubeProviderRSS:
+		apiKeySet = false
+		rss = info.NewYoutubeRSS()
+	default:
+		return feeds, nil, fmt.Errorf("unknown youtube provider %q", conf.YoutubeProvider)
+	}
+	if apiKeySet {
+		srv, err = info.NewYoutubeService(conf.GoogleAPIKeyFile)
+		if err != nil {
+			return feeds, nil, err
+		}
+	}
+
+	quota := info.NewYoutubeQuota(conf.YoutubeDailyQuota, conf.YoutubeQuotaReserve)
+	// broadcast status is available in the Data API only
+	if srv != nil && descriptions[0].Name == defaultYoutubeFeed {
+		live, err = info.NewYoutubeLive(srv, quota, conf.YoutubeChannelID, conf.ImageProxyURL)
+		if err != nil {
+			return feeds, nil, err
+		}
+		if rss != nil {
+			// RSS fallback must not bring broadcasts back into the feeds
+			rss.ExcludeBroadcasts(live)
+		}
+	}
+
+	for _, feed := range descriptions {
+		feed.ImageProxyURL = conf.ImageProxyURL
+		buf, err := info.NewYoutubeVideosBuffer(srv, quota, rss, feed)
+		if err != nil {
+			return feeds, nil, err
+		}
+		feeds[feed.Name] = buf
+	}
+	return feeds, live, nil
+}
+
+// parseYoutubeFeed parses feed source in form of channel/{channelID}[/{size}] or playlist/{playlistID}[/{size}]
+func parseYoutubeFeed(name, source string, defaultSize int) (info.YoutubeFeed, error) {
+	feed := info.YoutubeFeed{Name: name, Size: defaultSize}
+	parts := strings.Split(source, "/")
+	if len(parts) < 2 || len(parts) > 3 || parts[1] == "" {
+		return feed, fmt.Errorf("invalid youtube feed %q", source)
+	}
+	switch parts[0] {
+	case "channel":
+		feed.ChannelID = parts[1]
+	case "playlist":
+		feed.PlaylistID = parts[1]
+	default:
+		return feed, fmt.Errorf("unknown youtube feed type %q", parts[0])
+	}
+	if len(parts) == 3 {
+		size, err := strconv.Atoi(parts[2])
+		if err != nil || size <= 0 {
+			return feed, fmt.Errorf("invalid size of youtube feed %q", name)
+		}
+		feed.Size = size
+	}
+	return feed, nil
+}
+
+func buildCaptchaPolicy(conf *config) *captcha.Policy {
+	policy := captcha.NewPolicy(conf.GoogleRecaptchaAction, conf.GoogleRecaptchaScore)
+	for route, actions := range parseKeyValues(conf.CaptchaRouteActions) {
+		policy.AllowActions(route, strings.Split(actions, "|")...)
 	}
-	buf, err = info.NewYoutubeVideosBuffer(conf.YoutubeChannelID, conf.YoutubeBufferSize, conf.GoogleAPIKeyFile)
+	for action, score := range parseKeyValues(conf.CaptchaActionScores) {
+		threshold, err := strconv.ParseFloat(score, 32)
+		if err != nil {
+			log.Errorf("Invalid score %q of captcha action %q", score, action)
+			continue
+		}
+		policy.SetThreshold(action, float32(threshold))
+	}
+	return policy
+}
+
+func buildReviewQueue(conf *config) (*review.Queue, error) {
+	if captcha.FailureMode(conf.CaptchaFailurePolicy) != captcha.FailQueue {
+		return nil, nil
+	}
+	if conf.CaptchaReviewQueueFile == "" {
+		return nil, errors.New("environment variable CAPTCHA_REVIEW_QUEUE_FILE not set")
+	}
+	auditLog, err := audit.Open(conf.CaptchaReviewQueueFile)
 	if err != nil {
 		return nil, err
 	}
-	return buf, nil
+	return review.NewQueue(auditLog), nil
+}
+
+func buildCaptchaGuard(conf *config, verifier captcha.Verifier, queue *review.Queue) (*captcha.Guard, error) {
+	if verifier == nil {
+		return nil, errors.New("captcha verifier not initialized")
+	}
+
+	mode := captcha.FailureMode(conf.CaptchaFailurePolicy)
+	if mode == captcha.FailQueue && queue == nil {
+		log.Warn("Captcha review queue is not available, falling back to fail-closed policy")
+		mode = captcha.FailClosed
+	}
+	var openLimiter *rate.Limiter
+	if mode == captcha.FailOpen {
+		openLimiter = rate.NewLimiter(rate.Limit(conf.CaptchaFailOpenPerMinute)/60, conf.CaptchaFailOpenBurst)
+	}
+
+	breaker := captcha.NewBreaker(conf.CaptchaBreakerThreshold, time.Duration(conf.CaptchaBreakerCooldown)*time.Second)
+	return captcha.NewGuard(verifier, breaker, mode, openLimiter)
+}
+
+// buildAggregate describes sections of the aggregated response. Sections of the sources which are not
+// configured are left out of the response
+func buildAggregate(conf *config, build *snapshot.Snapshot, cma *info.CmaClient, videos *info.YoutubeBuffer,
+	gh *info.GitHubAggregator) *snapshot.Aggregate {
+	sections := []snapshot.Section{
+		{Name: "build", Source: func() *snapshot.Snapshot { return build }},
+		{Name: "tweets", Source: cma.FeedSnapshot, DefaultCount: conf.CmaLimit, MaxCount: conf.CmaLimit,
+			Render: func(s *snapshot.Snapshot, count int) ([]byte, error) {
+				tweets := s.Data.([]*info.TwitterInfo)
+				if count >= len(tweets) {
+					return s.JSON, nil
+				}
+				return json.Marshal(tweets[:count])
+			}},
+	}
+	if videos != nil {
+		sections = append(sections, snapshot.Section{Name: "youtube", Source: videos.Snapshot,
+			DefaultCount: defaultYoutubeRSCount, MaxCount: conf.YoutubeBufferSize,
+			Render: func(s *snapshot.Snapshot, count int) ([]byte, error) {
+				videos := s.Data.([]info.VideoInfo)
+				return json.Marshal(videos[:min(len(videos), count)])
+			}})
+	}
+	if gh != nil {
+		sections = append(sections,
+			snapshot.Section{Name: "github", Sections: []snapshot.Section{
+				{Name: "stars", Source: gh.StarsSnapshot},
+				{Name: "contribution_stats", Source: gh.ContributionSnapshot},
+				{Name: "issue_stats", Source: gh.IssueStatsSnapshot},
+			}},
+			snapshot.Section{Name: "latest_versions", Source: gh.LatestTagsSnapshot})
+	}
+	return snapshot.NewAggregate(sections...)
+}
+
+// buildEvents creates broker of the source updates and starts polling the sources for changes
+func buildEvents(conf *config, cma *info.CmaClient, gh *info.GitHubAggregator,
+	videos *info.YoutubeBuffer, live *info.YoutubeLive) *events.Broker {
+	broker := events.NewBroker(conf.EventsHistorySize, conf.EventsMaxSubscribers)
+	watcher := events.NewWatcher(broker)
+	// feed is fetched from Contentful once its cache expires, concurrent refreshes are deduplicated
+	watcher.Watch(eventTypeNews, cma.FeedSnapshot, info.NewsChanges)
+	if gh != nil {
+		watcher.Watch(eventTypeRelease, gh.LatestTagsSnapshot, info.ReleaseChanges)
+		watcher.Watch(eventTypeStars, gh.StarsSnapshot, info.StarsChanges)
+	}
+	if videos != nil {
+		watcher.Watch(eventTypeVideo, videos.Snapshot, info.VideoChanges)
+	}
+	if live != nil {
+		watcher.Watch(eventTypeLive, live.Snapshot, info.LiveChanges)
+	}
+	watcher.Start(eventsPollPeriod)
+	return broker
+}
+
+// buildOpenAPI describes parameters, bodies and responses of the public routes.
+// Routes without description are still listed in the document
+func buildOpenAPI(conf *config) *openapi.Spec {
+	api := openapi.New("ReportPortal Landing Aggregator", Version)
+	// webhooks and admin routes are not part of the public API
+	api.Hide("/admin", "/webhooks")
+	errorRS := map[string]string{}
+
+	api.Operation(http.MethodGet, "/").Describe("Aggregated landing info").Tag("info").
+		Query("include", openapi.String(), false).
+		Query("exclude", openapi.String(), false).
+		Query("tweets.count", openapi.Integer(0, conf.CmaLimit), false).
+		Query("youtube.count", openapi.Integer(0, conf.YoutubeBufferSize), false).
+		Query("jsonp", openapi.String(), false).
+		Response(http.StatusOK, "Selected sections", map[string]interface{}{}).
+		Response(http.StatusBadRequest, "Invalid query", errorRS)
+	api.Operation(http.MethodGet, "/info").Describe("Build info").Tag("info").
+		Response(http.StatusOK, "Build info", &commons.BuildInfo{})
+	api.Operation(http.MethodGet, "/twitter").Describe("News feed").Tag("news").
+		Query("count", openapi.Integer(1, conf.CmaLimit), false).
+		Query("jsonp", openapi.String(), false).
+		Response(http.StatusOK, "News items", []*info.TwitterInfo{}).
+		Response(http.StatusBadRequest, "Invalid query", errorRS)
+	for _, pattern := range []string{"/youtube", "/youtube/{feed}"} {
+		api.Operation(http.MethodGet, pattern).Describe("Videos of the feed").Tag("youtube").
+			Query("count", openapi.Integer(1, 0), false).
+			Query("jsonp", openapi.String(), false).
+			Response(http.StatusOK, "Videos", []info.VideoInfo{}).
+			Response(http.StatusBadRequest, "Invalid query", errorRS).
+			Response(http.StatusServiceUnavailable, "Feed is not initialized", errorRS)
+	}
+	api.Operation(http.MethodGet, "/youtube/live").Describe("Live and upcoming broadcasts").Tag("youtube").
+		Query("jsonp", openapi.String(), false).
+		Response(http.StatusOK, "Broadcasts", []info.LiveBroadcast{}).
+		Response(http.StatusServiceUnavailable, "Broadcasts are not initialized", errorRS)
+	api.Operation(http.MethodGet, "/versions").Describe("Latest versions of the repositories").Tag("github").
+		Query("jsonp", openapi.String(), false).
+		Response(http.StatusOK, "Versions by repository", map[string]string{})
+	api.Operation(http.MethodGet, "/github/stars").Describe("Stars of the repositories").Tag("github").
+		Response(http.StatusOK, "Stars", &info.Stars{})
+	api.Operation(http.MethodGet, "/github/contribution").Describe("Contribution stats").Tag("github").
+		Response(http.StatusOK, "Contribution stats", &info.ContributionStats{})
+	api.Operation(http.MethodGet, "/github/issues").Describe("Issue stats").Tag("github").
+		Response(http.StatusOK, "Issue stats", &info.IssueStats{})
+	api.Operation(http.MethodPost, "/subscriptions").Describe("Subscribe to the newsletter").Tag("newsletter").
+		Body(newsletter.SubscriptionRequest{}, "email_address").
+		Response(http.StatusOK, "Subscription", &newsletter.Subscription{}).
+		Response(http.StatusBadRequest, "Invalid request", errorRS)
+	api.Operation(http.MethodPost, "/mailchimp/lists/{listID}/members").Describe("Subscribe to the Mailchimp list").Tag("newsletter").
+		Body(newsletter.MailchimpMemberRequest{}, "email_address").
+		Response(http.StatusOK, "List member", &newsletter.MailchimpMember{}).
+		Response(http.StatusBadRequest, "Invalid request", errorRS)
+	api.Operation(http.MethodGet, "/events").Describe("Server-Sent Events of the source updates").Tag("events").
+		Response(http.StatusOK, "Event stream", nil)
+	return api
+}
+
+// buildImageProxy creates proxy of YouTube thumbnails and Contentful assets cached on disk.
+// The proxy is disabled unless IMAGE_PROXY_URL is set
+func buildImageProxy(conf *config) (*imgproxy.Proxy, error) {
+	if conf.ImageProxyURL == "" {
+		return nil, nil
+	}
+	dir := conf.ImageCacheDir
+	if dir == "" {
+		dir = filepath.Join(os.TempDir(), "landing-aggregator-images")
+	}
+	cache, err := imgproxy.NewDiskCache(dir, int64(conf.ImageCacheMaxMB)<<20)
+	if err != nil {
+		return nil, err
+	}
+	maxAge := time.Duration(conf.ImageMaxAgeHours) * time.Hour
+	return imgproxy.NewProxy(cache, int64(conf.ImageMaxSizeKB)<<10, maxAge), nil
+}
+
+// buildCORS creates CORS policies: read routes are open to CORS_ALLOWED_ORIGINS, subscription routes
+// to CORS_WRITE_ALLOWED_ORIGINS only, webhooks and admin routes are not available cross-origin
+func buildCORS(conf *config, router chi.Routes) (*cors.CORS, error) {
+	maxAge := time.Duration(conf.CORSMaxAge) * time.Second
+	handler, err := cors.New(router, cors.Policy{
+		AllowedOrigins: conf.CORSAllowedOrigins,
+		AllowedHeaders: []string{"Content-Type", "Last-Event-ID"},
+		MaxAge:         maxAge,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	writePolicy := func(origins []string) cors.Policy {
+		return cors.Policy{
+			AllowedOrigins:   origins,
+			AllowedMethods:   []string{http.MethodPost},
+			AllowedHeaders:   []string{"Content-Type", "RP-Recaptcha-Token", "RP-Recaptcha-Action"},
+			ExposedHeaders:   []string{"Retry-After"},
+			AllowCredentials: conf.CORSAllowCredentials,
+			MaxAge:           maxAge,
+		}
+	}
+	routes := map[string]cors.Policy{
+		"/subscriptions":       writePolicy(conf.CORSWriteAllowedOrigins),
+		"/mailchimp/":          writePolicy(conf.CORSWriteAllowedOrigins),
+		"/webhooks/mailchimp/": {},
+		"/admin/":              {},
+	}
+	for prefix, origins := range parseKeyValues(conf.CORSRouteOrigins) {
+		policy, ok := routes[prefix]
+		if !ok {
+			policy = cors.Policy{AllowedHeaders: []string{"Content-Type"}, MaxAge: maxAge}
+		}
+		policy.AllowedOrigins = strings.Split(origins, "|")
+		routes[prefix] = policy
+	}
+
+	for prefix, policy := range routes {
+		for _, origin := range policy.AllowedOrigins {
+			if origin == "*" && len(policy.AllowedMethods) > 0 {
+				return nil, fmt.Errorf("cors policy of %s: writes cannot be allowed from any origin", prefix)
+			}
+		}
+		if err = handler.Route(prefix, policy); err != nil {
+			return nil, err
+		}
+	}
+	return handler, nil
+}
+
+func buildSubscribeLimiter(conf *config) *ratelimit.Limiter {
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(time.Minute))
+	limiter.SetLimit(rateLimitScopeIP, ratelimit.PerMinute(conf.RateLimitIPPerMinute, conf.RateLimitIPBurst))
+	limiter.SetLimit(rateLimitScopeEmail, ratelimit.PerMinute(conf.RateLimitEmailPerMinute, conf.RateLimitEmailBurst))
+	limiter.SetLimit(rateLimitScopeList, ratelimit.PerMinute(conf.RateLimitListPerMinute, conf.RateLimitListBurst))
+	return limiter
+}
+
+func buildEmailValidator(conf *config) *email.Validator {
+	opts := []email.Option{email.WithDisposableDomains(conf.EmailDisposableDomains...)}
+
+	if conf.EmailDisposableDomainsFile != "" {
+		domains, err := email.LoadDomains(conf.EmailDisposableDomainsFile)
+		if err != nil {
+			log.Errorf("Cannot load disposable domains list: %v", err)
+		} else {
+			log.Infof("Loaded %d disposable domains", len(domains))
+			opts = append(opts, email.WithDisposableDomains(domains...))
+		}
+	}
+	if !conf.EmailSuggestTypos {
+		opts = append(opts, email.WithCommonDomains())
+	}
+	if conf.EmailMXLookup {
+		opts = append(opts, email.WithResolver(net.DefaultResolver, time.Duration(conf.EmailMXTimeout)*time.Second))
+	}
+	return email.NewValidator(opts...)
+}
+
+func buildConsentStore(conf *config) (*consent.Store, error) {
+	if conf.ConsentLogFile == "" {
+		return nil, errors.New("environment variable CONSENT_LOG_FILE not set")
+	}
+	auditLog, err := audit.Open(conf.ConsentLogFile)
+	if err != nil {
+		return nil, err
+	}
+	return consent.NewStore(auditLog), nil
+}
+
+func buildMailchimpWebhook(conf *config) (*newsletter.MailchimpWebhook, error) {
+	secrets := parseKeyValues(conf.MailchimpWebhookSecrets)
+	if len(secrets) == 0 {
+		return nil, errors.New("environment variable MAILCHIMP_WEBHOOK_SECRETS not set")
+	}
+
+	var auditLog *audit.Log
+	if conf.MailchimpWebhookAuditFile != "" {
+		var err error
+		if auditLog, err = audit.Open(conf.MailchimpWebhookAuditFile); err != nil {
+			return nil, err
+		}
+	}
+
+	var forward *newsletter.WebhookClient
+	if conf.MailchimpWebhookForwardURL != "" {
+		forward = newsletter.NewWebhookClient(
+			conf.MailchimpWebhookForwardURL,
+			conf.MailchimpWebhookForwardSecret,
+			time.Duration(conf.NewsletterTimeout)*time.Second,
+		)
+	}
+	return newsletter.NewMailchimpWebhook(secrets, auditLog, forward), nil
+}
+
+func buildSubscriber(conf *config, mailchimpClient *newsletter.MailchimpClient) (newsletter.Subscriber, error) {
+	timeout := time.Duration(conf.NewsletterTimeout) * time.Second
+
+	switch conf.NewsletterProvider {
+	case "mailchimp":
+		if mailchimpClient == nil {
+			return nil, errors.New("environment variable MAILCHIMP_API_KEY not set")
+		}
+		return mailchimpClient, nil
+	case "sendgrid":
+		if conf.SendGridAPIKey == "" {
+			return nil, errors.New("environment variable SENDGRID_API_KEY not set")
+		}
+		return newsletter.NewSendGridClient(conf.SendGridAPIKey, timeout), nil
+	case "brevo":
+		if conf.BrevoAPIKey == "" {
+			return nil, errors.New("environment variable BREVO_API_KEY not set")
+		}
+		brevo := newsletter.NewBrevoClient(conf.BrevoAPIKey, timeout)
+		brevo.DOITemplateID = conf.BrevoDOITemplateID
+		brevo.DOIRedirectURL = conf.BrevoDOIRedirectURL
+		return brevo, nil
+	case "webhook":
+		if conf.NewsletterWebhookURL == "" {
+			return nil, errors.New("environment variable NEWSLETTER_WEBHOOK_URL not set")
+		}
+		return newsletter.NewWebhookClient(conf.NewsletterWebhookURL, conf.NewsletterWebhookSecret, timeout), nil
+	default:
+		return nil, fmt.Errorf("unknown newsletter provider %q", conf.NewsletterProvider)
+	}
+}
+
+// parseKeyValues converts list of 'key:value' pairs into a map. Pairs without separator are skipped
+func parseKeyValues(pairs []string) map[string]string {
+	m := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		if k, v, ok := strings.Cut(pair, ":"); ok {
+			m[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+	return m
+}
+
+// videosRS writes requested count of the feed videos
+func videosRS(buffer *info.YoutubeBuffer, w http.ResponseWriter, rq *http.Request) {
+	if buffer == nil {
+		jsonpRS(http.StatusServiceUnavailable, map[string]string{"error": "youtube feed not initialized"}, w, rq)
+		return
+	}
+	count := getQueryIntParam(rq, "count", defaultYoutubeRSCount)
+	if size := buffer.Feed().Size; count > size {
+		jsonpRS(http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("provided count exceed max allower value (%d)", size)}, w, rq)
+		return
+	}
+	if httpcache.Check(w, rq, buffer.Snapshot()) {
+		return
+	}
+	jsonpRS(http.StatusOK, buffer.GetVideos(count), w, rq)
 }
 
 func getQueryIntParam(rq *http.Request, name string, def int) int {
@@ -269,8 +1103,34 @@ type config struct {
 	GoogleRecaptchaScore  float32 `env:"GOOGLE_RECAPTCHA_SCORE" envDefault:"0.5"`
 	GoogleRecaptchaAction string  `env:"GOOGLE_RECAPTCHA_ACTION" envDefault:"contact_us"`
 
+	CaptchaProvider string `env:"CAPTCHA_PROVIDER" envDefault:"recaptcha-enterprise"`
+	CaptchaSiteKey  string `env:"CAPTCHA_SITE_KEY"`
+	CaptchaSecret   string `env:"CAPTCHA_SECRET"`
+	CaptchaTimeout  int    `env:"CAPTCHA_TIMEOUT_SECONDS" envDefault:"3"`
+	// overrides siteverify endpoint, e.g. for egress proxies or test doubles
+	CaptchaVerifyURL string `env:"CAPTCHA_VERIFY_URL"`
+	// route:action1|action2 pairs, the first action is used when client does not send one
+	CaptchaRouteActions []string `env:"CAPTCHA_ROUTE_ACTIONS" envSeparator:","`
+	// action:score pairs overriding GOOGLE_RECAPTCHA_SCORE
+	CaptchaActionScores []string `env:"CAPTCHA_ACTION_SCORES" envSeparator:","`
+	// closed, open or queue
+	CaptchaFailurePolicy     string  `env:"CAPTCHA_FAILURE_POLICY" envDefault:"closed"`
+	CaptchaBreakerThreshold  int     `env:"CAPTCHA_BREAKER_THRESHOLD" envDefault:"5"`
+	CaptchaBreakerCooldown   int     `env:"CAPTCHA_BREAKER_COOLDOWN_SECONDS" envDefault:"30"`
+	CaptchaFailOpenPerMinute float64 `env:"CAPTCHA_FAIL_OPEN_PER_MINUTE" envDefault:"10"`
+	CaptchaFailOpenBurst     int     `env:"CAPTCHA_FAIL_OPEN_BURST" envDefault:"5"`
+	CaptchaReviewQueueFile   string  `env:"CAPTCHA_REVIEW_QUEUE_FILE"`
+	CaptchaAnnotations       bool    `env:"CAPTCHA_ANNOTATIONS" envDefault:"true"`
+
 	YoutubeBufferSize int    `env:"YOUTUBE_BUFFER_SIZE" envDefault:"10"`
 	YoutubeChannelID  string `env:"YOUTUBE_CHANNEL_ID" envDefault:"false"`
+	// name:channel/{channelID}[/{size}] or name:playlist/{playlistID}[/{size}] pairs
+	YoutubeFeeds []string `env:"YOUTUBE_FEEDS" envSeparator:","`
+	// auto (Data API with RSS fallback), api or rss
+	YoutubeProvider string `env:"YOUTUBE_PROVIDER" envDefault:"auto"`
+	// units of YouTube Data API quota per day and part of them never spent by refreshes
+	YoutubeDailyQuota   int `env:"YOUTUBE_DAILY_QUOTA" envDefault:"10000"`
+	YoutubeQuotaReserve int `env:"YOUTUBE_QUOTA_RESERVE" envDefault:"1000"`
 
 	CmaToken   string `env:"CONTENTFUL_TOKEN"`
 	CmaSpaceID string `env:"CONTENTFUL_SPACE_ID" envDefault:"1n1nntnzoxp4"`
@@ -279,50 +1139,421 @@ type config struct {
 	MailchimpAPIKey  string `env:"MAILCHIMP_API_KEY" envDefault:"false"`
 	MailchimpUser    string `env:"MAILCHIMP_USER" envDefault:"landing-aggregator"`
 	MailchimpTimeout int    `env:"MAILCHIMP_TIMEOUT_SECONDS" envDefault:"3"`
+
+	NewsletterProvider      string `env:"NEWSLETTER_PROVIDER" envDefault:"mailchimp"`
+	NewsletterListID        string `env:"NEWSLETTER_LIST_ID"`
+	NewsletterTimeout       int    `env:"NEWSLETTER_TIMEOUT_SECONDS" envDefault:"3"`
+	NewsletterWebhookURL    string `env:"NEWSLETTER_WEBHOOK_URL"`
+	NewsletterWebhookSecret string `env:"NEWSLETTER_WEBHOOK_SECRET"`
+
+	SendGridAPIKey string `env:"SENDGRID_API_KEY"`
+
+	BrevoAPIKey         string `env:"BREVO_API_KEY"`
+	BrevoDOITemplateID  int    `env:"BREVO_DOI_TEMPLATE_ID"`
+	BrevoDOIRedirectURL string `env:"BREVO_DOI_REDIRECT_URL"`
+
+	EmailMXLookup              bool     `env:"EMAIL_MX_LOOKUP" envDefault:"false"`
+	EmailMXTimeout             int      `env:"EMAIL_MX_TIMEOUT_SECONDS" envDefault:"2"`
+	EmailSuggestTypos          bool     `env:"EMAIL_SUGGEST_TYPOS" envDefault:"true"`
+	EmailDisposableDomains     []string `env:"EMAIL_DISPOSABLE_DOMAINS" envSeparator:","`
+	EmailDisposableDomainsFile string   `env:"EMAIL_DISPOSABLE_DOMAINS_FILE"`
+
+	MailchimpWebhookSecrets       []string `env:"MAILCHIMP_WEBHOOK_SECRETS" envSeparator:","`
+	MailchimpWebhookAuditFile     string   `env:"MAILCHIMP_WEBHOOK_AUDIT_FILE"`
+	MailchimpWebhookForwardURL    string   `env:"MAILCHIMP_WEBHOOK_FORWARD_URL"`
+	MailchimpWebhookForwardSecret string   `env:"MAILCHIMP_WEBHOOK_FORWARD_SECRET"`
+
+	MailchimpMarketingPermissions []string `env:"MAILCHIMP_MARKETING_PERMISSIONS" envSeparator:","`
+	MailchimpConsentMergeField    string   `env:"MAILCHIMP_CONSENT_MERGE_FIELD"`
+
+	ConsentLogFile     string `env:"CONSENT_LOG_FILE"`
+	ConsentTextVersion string `env:"CONSENT_TEXT_VERSION"`
+	ConsentRequired    bool   `env:"CONSENT_REQUIRED" envDefault:"false"`
+
+	AdminToken string `env:"ADMIN_TOKEN"`
+
+	// origins are exact (https://reportportal.io), wildcard subdomains (https://*.reportportal.io) or *.
+	// Write origins default to the landing page so that its newsletter form works without configuration
+	CORSAllowedOrigins      []string `env:"CORS_ALLOWED_ORIGINS" envSeparator:"," envDefault:"*"`
+	CORSWriteAllowedOrigins []string `env:"CORS_WRITE_ALLOWED_ORIGINS" envSeparator:"," envDefault:"https://reportportal.io,https://*.reportportal.io"`
+	CORSAllowCredentials    bool     `env:"CORS_ALLOW_CREDENTIALS" envDefault:"false"`
+	CORSMaxAge              int      `env:"CORS_MAX_AGE_SECONDS" envDefault:"86400"`
+	// path-prefix:origin1|origin2 pairs overriding origins of the routes
+	CORSRouteOrigins []string `env:"CORS_ROUTE_ORIGINS" envSeparator:","`
+
+	// number of latest events clients can resume from
+	EventsHistorySize    int `env:"EVENTS_HISTORY_SIZE" envDefault:"100"`
+	EventsMaxSubscribers int `env:"EVENTS_MAX_SUBSCRIBERS" envDefault:"1000"`
+
+	// public base URL of this service, enables image proxy and rewriting of thumbnail URLs
+	ImageProxyURL    string `env:"IMAGE_PROXY_URL"`
+	ImageCacheDir    string `env:"IMAGE_CACHE_DIR"`
+	ImageCacheMaxMB  int    `env:"IMAGE_CACHE_MAX_MB" envDefault:"200"`
+	ImageMaxSizeKB   int    `env:"IMAGE_MAX_SIZE_KB" envDefault:"2048"`
+	ImageMaxAgeHours int    `env:"IMAGE_MAX_AGE_HOURS" envDefault:"720"`
+
+	// addresses or CIDR ranges of reverse proxies allowed to set X-Forwarded-For and X-Real-IP
+	TrustedProxies []string `env:"TRUSTED_PROXIES" envSeparator:","`
+
+	// limits of subscription attempts, zero disables the limit
+	RateLimitIPPerMinute    float64 `env:"RATE_LIMIT_IP_PER_MINUTE" envDefault:"10"`
+	RateLimitIPBurst        int     `env:"RATE_LIMIT_IP_BURST" envDefault:"5"`
+	RateLimitEmailPerMinute float64 `env:"RATE_LIMIT_EMAIL_PER_MINUTE" envDefault:"0.2"`
+	RateLimitEmailBurst     int     `env:"RATE_LIMIT_EMAIL_BURST" envDefault:"3"`
+	RateLimitListPerMinute  float64 `env:"RATE_LIMIT_LIST_PER_MINUTE" envDefault:"300"`
+	RateLimitListBurst      int     `env:"RATE_LIMIT_LIST_BURST" envDefault:"60"`
+}
+
+// captchaSiteKey returns site key of the captcha provider. reCAPTCHA Enterprise falls back to GOOGLE_RECAPTCHA_KEY
+func (c *config) captchaSiteKey() string {
+	if c.CaptchaSiteKey == "" && c.CaptchaProvider == captcha.ProviderRecaptchaEnterprise {
+		return c.GoogleRecaptchaKey
+	}
+	return c.CaptchaSiteKey
 }
 
 var notFoundMiddleware = func(w http.ResponseWriter, rq *http.Request) {
 	jsonRS(http.StatusNotFound, map[string]string{"error": "not found"}, w)
 }
 
-var enableCORSMiddleware = func(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, rq *http.Request) {
-		w.Header().Add("Access-Control-Allow-Origin", "*")
-		next.ServeHTTP(w, rq)
-	})
+func checkMailchimpClient(client *newsletter.MailchimpClient, w http.ResponseWriter) bool {
+	if client == nil {
+		subscriptionErrorRS(newsletter.NewError(newsletter.CodeProviderUnavailable, "Mailchimp client not initialized"), w)
+		return false
+	}
+	return true
 }
 
-func checkMailchimpClient(client *info.MailchimpClient, w http.ResponseWriter) bool {
-	if client == nil {
-		jsonRS(http.StatusInternalServerError, map[string]string{"error": "Mailchimp client not initialized"}, w)
+func checkSubscriber(subscriber newsletter.Subscriber, w http.ResponseWriter) bool {
+	if subscriber == nil {
+		subscriptionErrorRS(newsletter.NewError(newsletter.CodeProviderUnavailable, "Newsletter provider not initialized"), w)
 		return false
 	}
 	return true
 }
 
-func checkCaptchaAssessment(conf *config, rq *http.Request, w http.ResponseWriter) bool {
-	token := rq.Header.Get("RP-Recaptcha-Token")
-	action := conf.GoogleRecaptchaAction
-
-	assessment, err := captcha.GetAssessment(
-		conf.GoogleProjectID,
-		conf.GoogleRecaptchaKey,
-		token,
-		action,
-	)
+// subscriptionErrorRS writes subscription failure with machine-readable code and corresponding HTTP status
+func subscriptionErrorRS(err error, w http.ResponseWriter) {
+	subErr := newsletter.AsError(err)
+	switch {
+	case subErr.Code == newsletter.CodeInvalidEmail || subErr.Code == newsletter.CodeInvalidRequest:
+		// rejected input of the user, not a failure of the service. Message may contain the address
+		log.Debugf("Subscription rejected: %s", subErr.Code)
+	case subErr.Err != nil:
+		log.Warnf("Subscription failed: %v", subErr)
+	}
+	rs := map[string]string{"error": subErr.Message, "code": string(subErr.Code)}
+	if subErr.Suggestion != "" {
+		rs["suggestion"] = subErr.Suggestion
+	}
+	jsonRS(subErr.HTTPStatus(), rs, w)
+}
+
+func checkMailchimpWebhook(hook *newsletter.MailchimpWebhook, w http.ResponseWriter, rq *http.Request) bool {
+	if hook == nil {
+		jsonRS(http.StatusNotFound, map[string]string{"error": "not found"}, w)
+		return false
+	}
+	switch err := hook.Authorize(chi.URLParam(rq, "profile"), rq.URL.Query().Get("secret")); {
+	case errors.Is(err, newsletter.ErrUnknownProfile):
+		jsonRS(http.StatusNotFound, map[string]string{"error": "not found"}, w)
+		return false
+	case err != nil:
+		jsonRS(http.StatusUnauthorized, map[string]string{"error": err.Error()}, w)
+		return false
+	}
+	return true
+}
+
+// adminAuthMiddleware allows only requests bearing admin token. All requests are rejected if token is not configured
+func adminAuthMiddleware(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, rq *http.Request) {
+			provided := strings.TrimPrefix(rq.Header.Get("Authorization"), "Bearer ")
+			if token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(provided)) != 1 {
+				jsonRS(http.StatusUnauthorized, map[string]string{"error": "unauthorized"}, w)
+				return
+			}
+			next.ServeHTTP(w, rq)
+		})
+	}
+}
+
+func checkConsentRequest(store *consent.Store, w http.ResponseWriter, rq *http.Request) (string, bool) {
+	if store == nil {
+		jsonRS(http.StatusServiceUnavailable, map[string]string{"error": "consent store not initialized"}, w)
+		return "", false
+	}
+	address := rq.URL.Query().Get("email")
+	if address == "" {
+		jsonRS(http.StatusBadRequest, map[string]string{"error": "email query parameter is required"}, w)
+		return "", false
+	}
+	return address, true
+}
+
+// consentTextVersion returns version of the consent text shown to the subscriber.
+// CONSENT_TEXT_VERSION is used if client provided none, unless CONSENT_REQUIRED is set
+func consentTextVersion(conf *config, provided string) (string, error) {
+	if provided != "" {
+		return provided, nil
+	}
+	if conf.ConsentRequired {
+		return "", newsletter.NewError(newsletter.CodeInvalidRequest, "consent text version is required")
+	}
+	return conf.ConsentTextVersion, nil
+}
+
+// eraseAddress removes the address from consent records, webhook audit log and review queue.
+// Webhook receiver and review queue are optional
+func eraseAddress(address string, store *consent.Store, hook *newsletter.MailchimpWebhook, queue *review.Queue) (map[string]int, error) {
+	erased := map[string]int{}
+	var err error
+	if erased["erased"], err = store.Erase(address); err != nil {
+		return nil, err
+	}
+	if hook != nil {
+		if erased["webhook_events"], err = hook.Erase(address); err != nil {
+			return nil, err
+		}
+	}
+	if queue != nil {
+		if erased["review_items"], err = queue.Erase(address); err != nil {
+			return nil, err
+		}
+	}
+	return erased, nil
+}
+
+// buildConsent collects consent details of the subscription request. Captcha result is nil if captcha was not verified
+func buildConsent(rq *http.Request, captchaResult *captcha.Result) *newsletter.Consent {
+	c := &newsletter.Consent{
+		IP:        clientIP(rq),
+		UserAgent: rq.UserAgent(),
+		Timestamp: time.Now().UTC(),
+	}
+	if captchaResult != nil {
+		c.CaptchaScore = &captchaResult.Score
+		c.CaptchaAssessment = captchaResult.AssessmentID
+	}
+	return c
+}
+
+// recordConsent stores proof of consent of the successful subscription
+func recordConsent(store *consent.Store, provider, listID, address, status string, c *newsletter.Consent) {
+	if store == nil || c == nil {
+		return
+	}
+	err := store.Add(&consent.Record{
+		Email:             address,
+		ListID:            listID,
+		Provider:          provider,
+		Status:            status,
+		Timestamp:         c.Timestamp,
+		IP:                c.IP,
+		UserAgent:         c.UserAgent,
+		TextVersion:       c.TextVersion,
+		CaptchaScore:      c.CaptchaScore,
+		CaptchaAssessment: c.CaptchaAssessment,
+	})
 	if err != nil {
-		jsonRS(http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("recaptcha assessment error: %v", err)}, w)
+		log.Errorf("Cannot record consent: %v", err)
+	}
+}
+
+// clientIP returns address of the client which sent the request
+func clientIP(rq *http.Request) string {
+	host, _, err := net.SplitHostPort(rq.RemoteAddr)
+	if err != nil {
+		return rq.RemoteAddr
+	}
+	return host
+}
+
+// checkSubscribeRateLimits applies client IP, email address and list limits to the subscription request.
+// Request body is read to find the address and list, and is replaced with a buffered copy
+func checkSubscribeRateLimits(limiter *ratelimit.Limiter, listID string, rq *http.Request, w http.ResponseWriter) bool {
+	if !checkRateLimit(limiter, rateLimitScopeIP, clientIP(rq), rq, w) {
 		return false
 	}
 
-	if assessment.GetRiskAnalysis().GetScore() < conf.GoogleRecaptchaScore {
-		jsonRS(http.StatusBadRequest, map[string]string{
-			"error":  "recaptcha assessment failed: low recaptcha score",
-			"score":  fmt.Sprint(assessment.GetRiskAnalysis().GetScore()),
-			"reason": fmt.Sprint(assessment.GetRiskAnalysis().GetReasons()[0]),
-		}, w)
+	body, err := io.ReadAll(http.MaxBytesReader(w, rq.Body, maxSubscriptionBodySize))
+	if err != nil {
+		jsonRS(http.StatusBadRequest, map[string]string{"error": "invalid request body"}, w)
 		return false
 	}
+	rq.Body = io.NopCloser(bytes.NewReader(body))
+
+	var target struct {
+		EmailAddress string `json:"email_address"`
+		ListID       string `json:"list_id"`
+	}
+	// malformed bodies are rejected later by the subscription parser
+	_ = json.Unmarshal(body, &target)
+	if target.ListID != "" && chi.URLParam(rq, "listID") == "" {
+		listID = target.ListID
+	}
 
+	return checkRateLimit(limiter, rateLimitScopeEmail, strings.TrimSpace(target.EmailAddress), rq, w) &&
+		checkRateLimit(limiter, rateLimitScopeList, listID, rq, w)
+}
+
+// checkRateLimit consumes a token of the key. Requests are let through if limit cannot be checked
+func checkRateLimit(limiter *ratelimit.Limiter, scope, key string, rq *http.Request, w http.ResponseWriter) bool {
+	err := limiter.Allow(rq.Context(), scope, key)
+	var limitErr *ratelimit.Error
+	switch {
+	case errors.As(err, &limitErr):
+		log.Warnf("Subscription rate limit per %s exceeded by %s", scope, clientIP(rq))
+		w.Header().Set("Retry-After", strconv.Itoa(limitErr.RetryAfterSeconds()))
+		jsonRS(http.StatusTooManyRequests, map[string]string{"error": "too many requests", "code": "rate_limited"}, w)
+		return false
+	case err != nil:
+		log.Errorf("Cannot check subscription rate limit: %v", err)
+	}
 	return true
 }
+
+// checkCaptchaAssessment verifies captcha token of the request and returns accepted result.
+// While captcha provider is unavailable request is rejected, let through with nil result
+// or deferred to the review queue, depending on the failure policy
+func checkCaptchaAssessment(guard *captcha.Guard, policy *captcha.Policy, queue *review.Queue, route, listID string, rq *http.Request, w http.ResponseWriter) (*captcha.Result, bool) {
+	if guard == nil {
+		jsonRS(http.StatusServiceUnavailable, map[string]string{"error": "captcha verifier not initialized"}, w)
+		return nil, false
+	}
+
+	action, err := policy.Action(route, rq.Header.Get("RP-Recaptcha-Action"))
+	if err != nil {
+		jsonRS(http.StatusBadRequest, map[string]string{"error": err.Error()}, w)
+		return nil, false
+	}
+
+	token := rq.Header.Get("RP-Recaptcha-Token")
+	result, err := guard.Verify(rq.Context(), token, action, clientIP(rq))
+	if err != nil && rq.Context().Err() != nil {
+		// client is gone, there is nobody to answer and the provider is not to blame
+		log.Debugf("Captcha verification of %s request canceled: %v", route, err)
+		return nil, false
+	}
+	if errors.Is(err, captcha.ErrUnavailable) {
+		log.Warnf("Captcha verification failed: %v", err)
+		switch guard.Fallback() {
+		case captcha.FailOpen:
+			log.Warnf("Captcha provider unavailable, letting %s request through", route)
+			return nil, true
+		case captcha.FailQueue:
+			deferCaptchaRequest(queue, route, listID, rq, w)
+			return nil, false
+		default:
+			jsonRS(http.StatusServiceUnavailable, map[string]string{"error": captcha.ErrUnavailable.Error()}, w)
+			return nil, false
+		}
+	}
+	if errors.Is(err, captcha.ErrTemporary) {
+		log.Warnf("Captcha verification failed: %v", err)
+		w.Header().Set("Retry-After", captchaRetryAfter)
+		jsonRS(http.StatusServiceUnavailable, map[string]string{"error": captcha.ErrTemporary.Error()}, w)
+		return nil, false
+	}
+	if err != nil {
+		jsonRS(http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("captcha verification error: %v", err)}, w)
+		return nil, false
+	}
+
+	if err = policy.Check(result, action); err != nil {
+		var rejection *captcha.Rejection
+		if errors.As(err, &rejection) {
+			jsonRS(http.StatusBadRequest, rejection.ResponseBody(), w)
+		} else {
+			jsonRS(http.StatusBadRequest, map[string]string{"error": err.Error()}, w)
+		}
+		return nil, false
+	}
+
+	return result, true
+}
+
+// annotateSubscription reports outcome of the subscription to the captcha provider: confirmed double opt-in
+// subscriptions are legitimate, cleaned (bounced) addresses are fraudulent. Other events are ignored
+func annotateSubscription(annotator captcha.Annotator, store *consent.Store, event *newsletter.MailchimpEvent) {
+	if event.Type != newsletter.MailchimpEventSubscribe && event.Type != newsletter.MailchimpEventCleaned {
+		return
+	}
+
+	record, err := store.Latest(event.Email, event.ListID)
+	if err != nil {
+		log.Errorf("Cannot read consent records: %v", err)
+		return
+	}
+	if record == nil || record.CaptchaAssessment == "" {
+		return
+	}
+
+	// subscribe event is sent for direct signups as well, only confirmation of the pending one proves the address owner
+	legitimate := event.Type == newsletter.MailchimpEventSubscribe
+	if legitimate && record.Status != string(newsletter.StatusPending) {
+		return
+	}
+
+	if err = annotator.Annotate(context.Background(), record.CaptchaAssessment, legitimate); err != nil {
+		log.Errorf("Cannot annotate captcha assessment: %v", err)
+		return
+	}
+	log.Infof("Captcha assessment of %s subscription annotated as legitimate=%t", event.Type, legitimate)
+}
+
+// deferCaptchaRequest stores request in the review queue and reports it as accepted
+func deferCaptchaRequest(queue *review.Queue, route, listID string, rq *http.Request, w http.ResponseWriter) {
+	var body bytes.Buffer
+	if _, err := body.ReadFrom(http.MaxBytesReader(w, rq.Body, maxSubscriptionBodySize)); err != nil {
+		jsonRS(http.StatusBadRequest, map[string]string{"error": "invalid request body"}, w)
+		return
+	}
+
+	item := &review.Item{
+		Route:     route,
+		ListID:    listID,
+		Body:      body.String(),
+		IP:        clientIP(rq),
+		UserAgent: rq.UserAgent(),
+	}
+	if err := queue.Add(item); err != nil {
+		log.Errorf("Cannot queue request for review: %v", err)
+		jsonRS(http.StatusServiceUnavailable, map[string]string{"error": captcha.ErrUnavailable.Error()}, w)
+		return
+	}
+	log.Warnf("Captcha provider unavailable, %s request queued for review as %s", route, item.ID)
+	jsonRS(http.StatusAccepted, map[string]string{"status": "queued"}, w)
+}
+
+func checkReviewQueue(queue *review.Queue, w http.ResponseWriter) bool {
+	if queue == nil {
+		jsonRS(http.StatusServiceUnavailable, map[string]string{"error": "review queue not initialized"}, w)
+		return false
+	}
+	return true
+}
+
+func checkReviewItem(queue *review.Queue, w http.ResponseWriter, rq *http.Request) (*review.Item, bool) {
+	if !checkReviewQueue(queue, w) {
+		return nil, false
+	}
+	item, err := queue.Get(chi.URLParam(rq, "id"))
+	switch {
+	case errors.Is(err, review.ErrNotFound):
+		jsonRS(http.StatusNotFound, map[string]string{"error": err.Error()}, w)
+		return nil, false
+	case err != nil:
+		log.Errorf("Cannot read review queue: %v", err)
+		jsonRS(http.StatusInternalServerError, map[string]string{"error": "cannot read review queue"}, w)
+		return nil, false
+	}
+	return item, true
+}
+
+func resolveReviewItem(queue *review.Queue, id, status string) {
+	if err := queue.Resolve(id, status); err != nil {
+		log.Errorf("Cannot resolve review item %s: %v", id, err)
+	}
+}
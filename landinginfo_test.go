@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/caarlos0/env/v6"
+	"github.com/go-chi/chi/v5"
+
+	"github.com/reportportal/landing-aggregator/info"
+	"github.com/reportportal/landing-aggregator/pkg/audit"
+	"github.com/reportportal/landing-aggregator/pkg/captcha"
+	"github.com/reportportal/landing-aggregator/pkg/consent"
+	"github.com/reportportal/landing-aggregator/pkg/newsletter"
+	"github.com/reportportal/landing-aggregator/pkg/snapshot"
+)
+
+type annotation struct {
+	assessment string
+	legitimate bool
+}
+
+type fakeAnnotator struct {
+	annotations []annotation
+}
+
+func (f *fakeAnnotator) Annotate(_ context.Context, assessmentID string, legitimate bool) error {
+	f.annotations = append(f.annotations, annotation{assessmentID, legitimate})
+	return nil
+}
+
+func TestAnnotateSubscription(t *testing.T) {
+	log, err := audit.Open(filepath.Join(t.TempDir(), "consents.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer log.Close()
+	store := consent.NewStore(log)
+	for _, r := range []*consent.Record{
+		{Email: "pending@example.com", ListID: "list", Status: "pending", CaptchaAssessment: "a1"},
+		{Email: "direct@example.com", ListID: "list", Status: "subscribed", CaptchaAssessment: "a2"},
+		{Email: "unverified@example.com", ListID: "list", Status: "pending"},
+	} {
+		if err = store.Add(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cases := []struct {
+		event    string
+		email    string
+		expected []annotation
+	}{
+		{newsletter.MailchimpEventSubscribe, "pending@example.com", []annotation{{"a1", true}}},
+		{newsletter.MailchimpEventSubscribe, "direct@example.com", nil},
+		{newsletter.MailchimpEventCleaned, "direct@example.com", []annotation{{"a2", false}}},
+		{newsletter.MailchimpEventSubscribe, "unverified@example.com", nil},
+		{newsletter.MailchimpEventSubscribe, "unknown@example.com", nil},
+		{newsletter.MailchimpEventUnsubscribe, "pending@example.com", nil},
+	}
+	for _, c := range cases {
+		annotator := &fakeAnnotator{}
+		annotateSubscription(annotator, store, &newsletter.MailchimpEvent{Type: c.event, Email: c.email, ListID: "list"})
+		if len(annotator.annotations) != len(c.expected) {
+			t.Errorf("%s %s: unexpected annotations %v", c.event, c.email, annotator.annotations)
+			continue
+		}
+		for i := range c.expected {
+			if annotator.annotations[i] != c.expected[i] {
+				t.Errorf("%s %s: expected %v, got %v", c.event, c.email, c.expected[i], annotator.annotations[i])
+			}
+		}
+	}
+}
+
+func TestReservedYoutubeFeedNames(t *testing.T) {
+	for _, name := range []string{defaultYoutubeFeed, youtubeFeedLive} {
+		conf := &config{YoutubeFeeds: []string{name + ":channel/UCxxxxxxxx"}}
+		if _, _, err := buildYoutubeFeeds(conf); err == nil {
+			t.Errorf("feed name %q must be rejected", name)
+		}
+	}
+}
+
+// blockingVerifier answers once the caller gives up
+type blockingVerifier struct{}
+
+func (blockingVerifier) Verify(ctx context.Context, _, _, _ string) (*captcha.Result, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestCaptchaAssessmentCanceled(t *testing.T) {
+	breaker := captcha.NewBreaker(1, time.Hour)
+	guard, err := captcha.NewGuard(blockingVerifier{}, breaker, captcha.FailClosed, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	rq := httptest.NewRequest(http.MethodPost, "/subscriptions", nil).WithContext(ctx)
+	w := httptest.NewRecorder()
+	if _, ok := checkCaptchaAssessment(guard, captcha.NewPolicy("newsletter", 0.5), nil, captchaRouteSubscriptions, "", rq, w); ok {
+		t.Fatal("canceled request must not pass")
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("response is written to the canceled request: %d %s", w.Code, w.Body)
+	}
+	if breaker.State() != captcha.BreakerClosed {
+		t.Errorf("canceled request is counted as provider failure, breaker is %s", breaker.State())
+	}
+}
+
+func TestDefaultWriteOrigins(t *testing.T) {
+	conf := &config{}
+	if err := env.Parse(conf); err != nil {
+		t.Fatal(err)
+	}
+	router := chi.NewMux()
+	router.Post("/subscriptions", func(w http.ResponseWriter, rq *http.Request) {})
+	corsHandler, err := buildCORS(conf, router)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for origin, allowed := range map[string]bool{
+		"https://reportportal.io":      true,
+		"https://demo.reportportal.io": true,
+		"https://example.com":          false,
+	} {
+		rq := httptest.NewRequest(http.MethodOptions, "/subscriptions", nil)
+		rq.Header.Set("Origin", origin)
+		rq.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		w := httptest.NewRecorder()
+		corsHandler.Handler(router).ServeHTTP(w, rq)
+		if got := w.Header().Get("Access-Control-Allow-Origin") == origin; got != allowed {
+			t.Errorf("%s: expected allowed %t, got headers %v", origin, allowed, w.Header())
+		}
+	}
+}
+
+func TestAggregateWithoutSources(t *testing.T) {
+	conf := &config{CmaLimit: 5, YoutubeBufferSize: 10}
+	aggregate := buildAggregate(conf, snapshot.Static(map[string]string{"version": "1.0"}),
+		info.NewCma("space", "token", conf.CmaLimit), nil, nil)
+
+	for _, section := range []string{"youtube", "github", "github.stars", "latest_versions"} {
+		if _, err := aggregate.ParseQuery(url.Values{"include": {section}}); err == nil {
+			t.Errorf("%s: expected disabled section to be rejected", section)
+		}
+	}
+
+	// tweets are excluded to not reach Contentful
+	query, err := aggregate.ParseQuery(url.Values{"exclude": {"tweets"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rendered, err := aggregate.Render(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, body := rendered.Body.Negotiate(""); string(body) != `{"build":{"version":"1.0"}}`+"\n" {
+		t.Errorf("unexpected body %s", body)
+	}
+}
@@ -0,0 +1,130 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const maxRecordSize = 1 << 20
+
+// Log is an append-only file of JSON records, one record per line
+type Log struct {
+	path string
+	mu   sync.Mutex
+	f    *os.File
+}
+
+// Open opens or creates log file. Parent directories are created if missing
+func Open(path string) (*Log, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &Log{path: path, f: f}, nil
+}
+
+// Append writes record to the end of the log
+func (l *Log) Append(record interface{}) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, err = l.f.Write(line)
+	return err
+}
+
+// Scan calls provided function for each record of the log in order they were appended
+func (l *Log) Scan(f func(record json.RawMessage) error) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	r, err := os.Open(l.path)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxRecordSize)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		if err = f(scanner.Bytes()); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// Rewrite replaces the log with records accepted by keep function and returns count of dropped records.
+// It is the only way to remove data from the log and is meant for erasure requests
+func (l *Log) Rewrite(keep func(record json.RawMessage) bool) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	r, err := os.Open(l.path)
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(l.path), filepath.Base(l.path)+".*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+
+	dropped := 0
+	w := bufio.NewWriter(tmp)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxRecordSize)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		if !keep(scanner.Bytes()) {
+			dropped++
+			continue
+		}
+		w.Write(scanner.Bytes())
+		w.WriteByte('\n')
+	}
+	if err = scanner.Err(); err != nil {
+		tmp.Close()
+		return 0, err
+	}
+	if err = w.Flush(); err != nil {
+		tmp.Close()
+		return 0, err
+	}
+	if err = tmp.Close(); err != nil {
+		return 0, err
+	}
+
+	if err = os.Rename(tmp.Name(), l.path); err != nil {
+		return 0, err
+	}
+	l.f.Close()
+	if l.f, err = os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600); err != nil {
+		return 0, err
+	}
+	return dropped, nil
+}
+
+// Close closes underlying file
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.f.Close()
+}
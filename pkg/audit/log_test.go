@@ -0,0 +1,88 @@
+package audit
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+)
+
+type record struct {
+	ID int `json:"id"`
+}
+
+func readAll(t *testing.T, l *Log) []int {
+	t.Helper()
+	var ids []int
+	err := l.Scan(func(raw json.RawMessage) error {
+		var r record
+		if err := json.Unmarshal(raw, &r); err != nil {
+			return err
+		}
+		ids = append(ids, r.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ids
+}
+
+func TestAppendAndScan(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "audit.log")
+	l, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 3; i++ {
+		if err = l.Append(&record{ID: i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// records survive reopening
+	if l, err = Open(path); err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if err = l.Append(&record{ID: 4}); err != nil {
+		t.Fatal(err)
+	}
+	if ids := readAll(t, l); len(ids) != 4 || ids[0] != 1 || ids[3] != 4 {
+		t.Errorf("unexpected records %v", ids)
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	l, err := Open(filepath.Join(t.TempDir(), "audit.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	for i := 1; i <= 4; i++ {
+		if err = l.Append(&record{ID: i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dropped, err := l.Rewrite(func(raw json.RawMessage) bool {
+		var r record
+		return json.Unmarshal(raw, &r) == nil && r.ID%2 == 0
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dropped != 2 {
+		t.Errorf("expected 2 dropped records, got %d", dropped)
+	}
+
+	// appends go to the rewritten file
+	if err = l.Append(&record{ID: 5}); err != nil {
+		t.Fatal(err)
+	}
+	if ids := readAll(t, l); len(ids) != 3 || ids[0] != 2 || ids[1] != 4 || ids[2] != 5 {
+		t.Errorf("unexpected records %v", ids)
+	}
+}
@@ -0,0 +1,38 @@
+package captcha
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	b := NewBreaker(2, 20*time.Millisecond)
+
+	b.Failure()
+	if !b.Allow() {
+		t.Fatal("breaker must stay closed below threshold")
+	}
+	b.Failure()
+	if b.Allow() || b.State() != BreakerOpen {
+		t.Fatalf("breaker must open after threshold, got %s", b.State())
+	}
+
+	time.Sleep(25 * time.Millisecond)
+	if !b.Allow() {
+		t.Fatal("breaker must let a probe through after cooldown")
+	}
+	if b.Allow() {
+		t.Fatal("breaker must let only one probe through")
+	}
+	b.Failure()
+	if b.State() != BreakerOpen {
+		t.Fatalf("failed probe must reopen breaker, got %s", b.State())
+	}
+
+	time.Sleep(25 * time.Millisecond)
+	b.Allow()
+	b.Success()
+	if b.State() != BreakerClosed {
+		t.Fatalf("successful probe must close breaker, got %s", b.State())
+	}
+}
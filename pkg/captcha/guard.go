@@ -0,0 +1,90 @@
+package captcha
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+)
+
+// FailureMode defines what happens with requests while captcha provider is unavailable
+type FailureMode string
+
+const (
+	// FailClosed rejects requests
+	FailClosed FailureMode = "closed"
+	// FailOpen lets requests through without verification, limited by rate
+	FailOpen FailureMode = "open"
+	// FailQueue defers requests to the review queue
+	FailQueue FailureMode = "queue"
+)
+
+var (
+	// ErrUnavailable is returned while the circuit breaker is open, failure mode applies to such requests
+	ErrUnavailable = errors.New("captcha verification unavailable")
+	// ErrTemporary is returned when provider call fails while the breaker is still closed. Request may be retried
+	ErrTemporary = errors.New("captcha verification failed, retry later")
+)
+
+// Guard is a Verifier protecting provider with circuit breaker and
+// deciding how to handle requests while the provider is unavailable
+type Guard struct {
+	verifier Verifier
+	breaker  *Breaker
+	mode     FailureMode
+	open     *rate.Limiter
+}
+
+// NewGuard wraps verifier with the breaker. Fail-open limiter is required for FailOpen mode only
+func NewGuard(verifier Verifier, breaker *Breaker, mode FailureMode, open *rate.Limiter) (*Guard, error) {
+	switch mode {
+	case FailClosed, FailQueue:
+	case FailOpen:
+		if open == nil {
+			return nil, errors.New("fail-open mode requires rate limiter")
+		}
+	default:
+		return nil, fmt.Errorf("unknown captcha failure mode %q", mode)
+	}
+	return &Guard{verifier: verifier, breaker: breaker, mode: mode, open: open}, nil
+}
+
+// Verify verifies the token unless breaker is open. Provider failures are reported as ErrTemporary
+// until they trip the breaker and as ErrUnavailable after that. Calls canceled by the client are not counted
+func (g *Guard) Verify(ctx context.Context, token, action, remoteIP string) (*Result, error) {
+	if !g.breaker.Allow() {
+		return nil, ErrUnavailable
+	}
+
+	result, err := g.verifier.Verify(ctx, token, action, remoteIP)
+	if err != nil {
+		if ctx.Err() != nil {
+			g.breaker.Abort()
+			return nil, ctx.Err()
+		}
+		g.breaker.Failure()
+		if g.breaker.State() != BreakerClosed {
+			log.Warnf("Captcha provider circuit breaker is open: %v", err)
+			return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+		}
+		return nil, fmt.Errorf("%w: %v", ErrTemporary, err)
+	}
+	g.breaker.Success()
+	return result, nil
+}
+
+// Fallback returns how request should be handled when verification is unavailable.
+// Fail-open mode falls back to fail-closed once its rate limit is exhausted
+func (g *Guard) Fallback() FailureMode {
+	if g.mode == FailOpen && !g.open.Allow() {
+		return FailClosed
+	}
+	return g.mode
+}
+
+// State returns state of the circuit breaker
+func (g *Guard) State() string {
+	return g.breaker.State()
+}
@@ -0,0 +1,123 @@
+package captcha
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// fakeVerifier fails with err unless it is nil and counts calls
+type fakeVerifier struct {
+	err   error
+	calls int
+}
+
+func (f *fakeVerifier) Verify(ctx context.Context, _, action, _ string) (*Result, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return &Result{Valid: true, Score: 1, Action: action}, nil
+}
+
+func TestGuardTrip(t *testing.T) {
+	verifier := &fakeVerifier{err: errors.New("connection refused")}
+	guard, err := NewGuard(verifier, NewBreaker(2, time.Hour), FailClosed, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// failure below threshold is retryable, fallback does not apply yet
+	if _, err = guard.Verify(context.Background(), "token", "newsletter", ""); !errors.Is(err, ErrTemporary) {
+		t.Fatalf("expected temporary error, got %v", err)
+	}
+	if _, err = guard.Verify(context.Background(), "token", "newsletter", ""); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("expected breaker to open, got %v", err)
+	}
+	if _, err = guard.Verify(context.Background(), "token", "newsletter", ""); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("expected unavailable while breaker is open, got %v", err)
+	}
+	if verifier.calls != 2 {
+		t.Errorf("provider must not be called while breaker is open, got %d calls", verifier.calls)
+	}
+}
+
+func TestGuardHalfOpen(t *testing.T) {
+	verifier := &fakeVerifier{err: errors.New("connection refused")}
+	guard, err := NewGuard(verifier, NewBreaker(1, 20*time.Millisecond), FailClosed, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = guard.Verify(context.Background(), "token", "newsletter", ""); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("expected breaker to open, got %v", err)
+	}
+
+	time.Sleep(25 * time.Millisecond)
+	if guard.State() != BreakerHalfOpen {
+		t.Fatalf("expected half-open breaker, got %s", guard.State())
+	}
+	verifier.err = nil
+	result, err := guard.Verify(context.Background(), "token", "newsletter", "")
+	if err != nil || !result.Valid {
+		t.Fatalf("expected successful probe, got %v", err)
+	}
+	if guard.State() != BreakerClosed {
+		t.Errorf("successful probe must close breaker, got %s", guard.State())
+	}
+}
+
+func TestGuardIgnoresCanceledRequests(t *testing.T) {
+	verifier := &fakeVerifier{}
+	breaker := NewBreaker(2, 20*time.Millisecond)
+	guard, err := NewGuard(verifier, breaker, FailOpen, rate.NewLimiter(rate.Inf, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for i := 0; i < 5; i++ {
+		if _, err = guard.Verify(ctx, "token", "newsletter", ""); !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected canceled verification, got %v", err)
+		}
+	}
+	if guard.State() != BreakerClosed {
+		t.Fatalf("canceled requests must not open breaker, got %s", guard.State())
+	}
+
+	// canceled probe does not block the next one
+	breaker.Failure()
+	breaker.Failure()
+	time.Sleep(25 * time.Millisecond)
+	if _, err = guard.Verify(ctx, "token", "newsletter", ""); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected canceled probe, got %v", err)
+	}
+	if _, err = guard.Verify(context.Background(), "token", "newsletter", ""); err != nil {
+		t.Fatalf("expected next probe to reach provider, got %v", err)
+	}
+}
+
+func TestGuardFailOpenLimit(t *testing.T) {
+	if _, err := NewGuard(&fakeVerifier{}, NewBreaker(1, time.Hour), FailOpen, nil); err == nil {
+		t.Fatal("fail-open mode without limiter must be rejected")
+	}
+
+	guard, err := NewGuard(&fakeVerifier{}, NewBreaker(1, time.Hour), FailOpen, rate.NewLimiter(rate.Every(time.Hour), 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if mode := guard.Fallback(); mode != FailOpen {
+			t.Fatalf("request %d: expected fail-open, got %s", i, mode)
+		}
+	}
+	if mode := guard.Fallback(); mode != FailClosed {
+		t.Errorf("exhausted fail-open limit must fall back to fail-closed, got %s", mode)
+	}
+}
@@ -0,0 +1,108 @@
+package captcha
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Rejection describes why verification result is not accepted
+type Rejection struct {
+	Message string
+	Score   *float32
+	Reasons []string
+}
+
+func (r *Rejection) Error() string {
+	return r.Message
+}
+
+// ResponseBody renders rejection the way captcha errors are reported to clients
+func (r *Rejection) ResponseBody() map[string]string {
+	body := map[string]string{"error": r.Message}
+	if r.Score != nil {
+		body["score"] = fmt.Sprint(*r.Score)
+	}
+	if len(r.Reasons) > 0 {
+		body["reason"] = strings.Join(r.Reasons, ", ")
+	}
+	return body
+}
+
+// Policy holds actions allowed for each route and minimal score of each action
+type Policy struct {
+	defaultAction    string
+	defaultThreshold float32
+	routes           map[string][]string
+	thresholds       map[string]float32
+}
+
+// NewPolicy creates policy where every route allows only the default action with the default threshold
+func NewPolicy(defaultAction string, defaultThreshold float32) *Policy {
+	return &Policy{
+		defaultAction:    defaultAction,
+		defaultThreshold: defaultThreshold,
+		routes:           map[string][]string{},
+		thresholds:       map[string]float32{},
+	}
+}
+
+// AllowActions sets actions allowed for the route. The first one is used when client does not send any action
+func (p *Policy) AllowActions(route string, actions ...string) {
+	p.routes[route] = actions
+}
+
+// SetThreshold sets minimal score of the action
+func (p *Policy) SetThreshold(action string, score float32) {
+	p.thresholds[action] = score
+}
+
+// Action resolves action the client token should be verified against.
+// Requested action must be in the route allow-list, empty one falls back to the route default
+func (p *Policy) Action(route, requested string) (string, error) {
+	allowed, ok := p.routes[route]
+	if !ok || len(allowed) == 0 {
+		allowed = []string{p.defaultAction}
+	}
+
+	if requested == "" {
+		return allowed[0], nil
+	}
+	for _, action := range allowed {
+		if action == requested {
+			return action, nil
+		}
+	}
+	return "", &Rejection{Message: fmt.Sprintf("captcha action %q is not allowed", requested)}
+}
+
+// Threshold returns minimal score of the action
+func (p *Policy) Threshold(action string) float32 {
+	if score, ok := p.thresholds[action]; ok {
+		return score
+	}
+	return p.defaultThreshold
+}
+
+// Check accepts verification result if token is valid, issued for expected action and scored high enough
+func (p *Policy) Check(result *Result, action string) error {
+	if !result.Valid {
+		return &Rejection{Message: "captcha verification failed: invalid token", Reasons: result.Reasons}
+	}
+
+	// hCaptcha and Turnstile tokens are not bound to actions
+	if result.ActionAware && result.Action != action {
+		return &Rejection{
+			Message: fmt.Sprintf("captcha verification failed: action mismatch: got %q want %q", result.Action, action),
+		}
+	}
+
+	if result.Score < p.Threshold(action) {
+		score := result.Score
+		return &Rejection{
+			Message: "captcha verification failed: low captcha score",
+			Score:   &score,
+			Reasons: result.Reasons,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package captcha
+
+import "testing"
+
+func TestPolicyAction(t *testing.T) {
+	p := NewPolicy("contact_us", 0.5)
+	p.AllowActions("subscriptions", "newsletter", "contact_us")
+
+	cases := []struct {
+		route, requested, expected string
+		rejected                   bool
+	}{
+		{"subscriptions", "", "newsletter", false},
+		{"subscriptions", "contact_us", "contact_us", false},
+		{"subscriptions", "login", "", true},
+		{"mailchimp", "", "contact_us", false},
+		{"mailchimp", "newsletter", "", true},
+	}
+	for _, c := range cases {
+		action, err := p.Action(c.route, c.requested)
+		if c.rejected != (err != nil) {
+			t.Errorf("%s/%s: unexpected error %v", c.route, c.requested, err)
+		}
+		if action != c.expected {
+			t.Errorf("%s/%s: expected action %q, got %q", c.route, c.requested, c.expected, action)
+		}
+	}
+}
+
+func TestPolicyCheck(t *testing.T) {
+	p := NewPolicy("contact_us", 0.5)
+	p.SetThreshold("newsletter", 0.3)
+
+	cases := []struct {
+		result   *Result
+		action   string
+		rejected bool
+	}{
+		{&Result{Valid: true, Score: 0.4, Action: "newsletter", ActionAware: true}, "newsletter", false},
+		{&Result{Valid: true, Score: 0.4, Action: "contact_us", ActionAware: true}, "contact_us", true},
+		{&Result{Valid: true, Score: 0.9, Action: "login", ActionAware: true}, "contact_us", true},
+		{&Result{Valid: true, Score: 0.9, ActionAware: true}, "contact_us", true},
+		{&Result{Valid: true, Score: 0.9}, "contact_us", false},
+		{&Result{Valid: true, Score: 0.9, Action: "login"}, "contact_us", false},
+		{&Result{Valid: false, Reasons: []string{"EXPIRED"}}, "contact_us", true},
+	}
+	for i, c := range cases {
+		if err := p.Check(c.result, c.action); c.rejected != (err != nil) {
+			t.Errorf("case %d: unexpected result %v", i, err)
+		}
+	}
+}
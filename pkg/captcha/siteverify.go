@@ -0,0 +1,115 @@
+package captcha
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/dghubble/sling"
+)
+
+const (
+	recaptchaVerifyURL = "https://www.google.com/recaptcha/api/siteverify"
+	hcaptchaVerifyURL  = "https://api.hcaptcha.com/siteverify"
+	turnstileVerifyURL = "https://challenges.cloudflare.com/turnstile/v0/siteverify"
+)
+
+type siteverifyRQ struct {
+	Secret   string `url:"secret"`
+	Response string `url:"response"`
+	RemoteIP string `url:"remoteip,omitempty"`
+	SiteKey  string `url:"sitekey,omitempty"`
+}
+
+type siteverifyRS struct {
+	Success    bool     `json:"success"`
+	Score      *float32 `json:"score"`
+	Action     string   `json:"action"`
+	Hostname   string   `json:"hostname"`
+	ErrorCodes []string `json:"error-codes"`
+	// ScoreReason is reported by hCaptcha Enterprise only
+	ScoreReason []string `json:"score_reason"`
+}
+
+// SiteverifyVerifier verifies tokens through siteverify-compatible API:
+// classic reCAPTCHA v3, hCaptcha and Cloudflare Turnstile
+type SiteverifyVerifier struct {
+	client  *sling.Sling
+	secret  string
+	siteKey string
+	// invertScore is set for providers reporting risk (1.0 - bot) instead of legitimacy
+	invertScore bool
+	// actionAware is set for providers binding tokens to actions
+	actionAware bool
+}
+
+// NewRecaptchaV3Verifier creates verifier of classic reCAPTCHA v3
+func NewRecaptchaV3Verifier(secret string, timeout time.Duration) *SiteverifyVerifier {
+	v := newSiteverifyVerifier(recaptchaVerifyURL, secret, timeout)
+	v.actionAware = true
+	return v
+}
+
+// NewHCaptchaVerifier creates hCaptcha verifier. Site key is optional and protects from tokens issued for other sites
+func NewHCaptchaVerifier(secret, siteKey string, timeout time.Duration) *SiteverifyVerifier {
+	v := newSiteverifyVerifier(hcaptchaVerifyURL, secret, timeout)
+	v.siteKey = siteKey
+	v.invertScore = true
+	return v
+}
+
+// NewTurnstileVerifier creates Cloudflare Turnstile verifier
+func NewTurnstileVerifier(secret string, timeout time.Duration) *SiteverifyVerifier {
+	return newSiteverifyVerifier(turnstileVerifyURL, secret, timeout)
+}
+
+func newSiteverifyVerifier(url, secret string, timeout time.Duration) *SiteverifyVerifier {
+	return &SiteverifyVerifier{
+		client: sling.New().Post(url).Client(&http.Client{Timeout: timeout}),
+		secret: secret,
+	}
+}
+
+// withURL replaces siteverify endpoint of the provider unless provided URL is empty
+func (v *SiteverifyVerifier) withURL(url string) *SiteverifyVerifier {
+	if url != "" {
+		v.client.Post(url)
+	}
+	return v
+}
+
+// Verify checks the token. Action is not sent to the provider, it is reported back and compared by the caller
+func (v *SiteverifyVerifier) Verify(ctx context.Context, token, _, remoteIP string) (*Result, error) {
+	rq, err := v.client.New().BodyForm(&siteverifyRQ{
+		Secret:   v.secret,
+		Response: token,
+		RemoteIP: remoteIP,
+		SiteKey:  v.siteKey,
+	}).Request()
+	if err != nil {
+		return nil, err
+	}
+
+	var body siteverifyRS
+	rs, err := v.client.Do(rq.WithContext(ctx), &body, nil)
+	if err != nil {
+		return nil, fmt.Errorf("siteverify: %w", err)
+	}
+	if rs.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("siteverify: unexpected response status %s", rs.Status)
+	}
+
+	if !body.Success {
+		return &Result{Valid: false, Action: body.Action, ActionAware: v.actionAware, Reasons: body.ErrorCodes}, nil
+	}
+
+	result := &Result{Valid: true, Score: 1, Action: body.Action, ActionAware: v.actionAware, Reasons: body.ScoreReason}
+	if body.Score != nil {
+		result.Score = *body.Score
+		if v.invertScore {
+			result.Score = 1 - *body.Score
+		}
+	}
+	return result, nil
+}
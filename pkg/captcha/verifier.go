@@ -0,0 +1,79 @@
+package captcha
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Result is a provider-neutral result of captcha token verification
+type Result struct {
+	// Valid reports whether token is genuine, not expired and not reused
+	Valid bool `json:"valid"`
+	// Score is a likelihood of the interaction being legitimate, from 0.0 (bot) to 1.0 (human).
+	// Providers without risk scoring report 1.0 for valid tokens
+	Score float32 `json:"score"`
+	// Action is an action name the token was issued for, if provider supports actions
+	Action string `json:"action,omitempty"`
+	// ActionAware is set by providers binding every token to an action, so that missing action is not accepted
+	ActionAware bool `json:"-"`
+	// Reasons explain invalid tokens or low scores
+	Reasons []string `json:"reasons,omitempty"`
+	// AssessmentID identifies assessment of providers supporting annotations
+	AssessmentID string `json:"assessment_id,omitempty"`
+}
+
+// Verifier verifies captcha tokens issued to the clients.
+// Error is returned only if verification cannot be performed, rejected tokens are reported through Result
+type Verifier interface {
+	Verify(ctx context.Context, token, action, remoteIP string) (*Result, error)
+}
+
+// Annotator reports the real outcome of previously assessed interaction back to the provider,
+// so that its risk model learns the site traffic
+type Annotator interface {
+	Annotate(ctx context.Context, assessmentID string, legitimate bool) error
+}
+
+// Provider names accepted by NewVerifier
+const (
+	ProviderRecaptchaEnterprise = "recaptcha-enterprise"
+	ProviderRecaptchaV3         = "recaptcha-v3"
+	ProviderHCaptcha            = "hcaptcha"
+	ProviderTurnstile           = "turnstile"
+)
+
+// Config holds settings of all supported captcha providers
+type Config struct {
+	Provider string
+	// ProjectID is a Google Cloud project of reCAPTCHA Enterprise
+	ProjectID string
+	// SiteKey is a public key of the site. Required for reCAPTCHA Enterprise, optional for hCaptcha
+	SiteKey string
+	// Secret is a server-side secret of siteverify-based providers
+	Secret string
+	// Timeout limits single verification call
+	Timeout time.Duration
+	// VerifyURL overrides siteverify endpoint of siteverify-based providers
+	VerifyURL string
+}
+
+// NewVerifier creates verifier of the configured provider
+func NewVerifier(cfg Config) (Verifier, error) {
+	switch cfg.Provider {
+	case ProviderRecaptchaEnterprise:
+		v, err := NewEnterpriseVerifier(context.Background(), cfg.ProjectID, cfg.SiteKey, cfg.Timeout)
+		if err != nil {
+			return nil, err
+		}
+		return v, nil
+	case ProviderRecaptchaV3:
+		return NewRecaptchaV3Verifier(cfg.Secret, cfg.Timeout).withURL(cfg.VerifyURL), nil
+	case ProviderHCaptcha:
+		return NewHCaptchaVerifier(cfg.Secret, cfg.SiteKey, cfg.Timeout).withURL(cfg.VerifyURL), nil
+	case ProviderTurnstile:
+		return NewTurnstileVerifier(cfg.Secret, cfg.Timeout).withURL(cfg.VerifyURL), nil
+	default:
+		return nil, fmt.Errorf("unknown captcha provider %q", cfg.Provider)
+	}
+}
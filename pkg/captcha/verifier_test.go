@@ -0,0 +1,264 @@
+package captcha
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	recaptchapb "cloud.google.com/go/recaptchaenterprise/v2/apiv1/recaptchaenterprisepb"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// siteverify starts fake siteverify endpoint answering with provided body and recording the form
+func siteverify(t *testing.T, status int, body string) (string, *http.Request) {
+	received := &http.Request{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, rq *http.Request) {
+		if err := rq.ParseForm(); err != nil {
+			t.Error(err)
+		}
+		*received = *rq
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL, received
+}
+
+func TestRecaptchaV3Verifier(t *testing.T) {
+	url, received := siteverify(t, http.StatusOK, `{"success":true,"score":0.7,"action":"newsletter"}`)
+	v, err := NewVerifier(Config{Provider: ProviderRecaptchaV3, Secret: "s3cr3t", Timeout: time.Second, VerifyURL: url})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := v.Verify(context.Background(), "token", "newsletter", "10.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Valid || result.Score != 0.7 || result.Action != "newsletter" || !result.ActionAware {
+		t.Errorf("unexpected result %+v", result)
+	}
+	if received.PostForm.Get("secret") != "s3cr3t" || received.PostForm.Get("response") != "token" ||
+		received.PostForm.Get("remoteip") != "10.0.0.1" {
+		t.Errorf("unexpected form %v", received.PostForm)
+	}
+}
+
+func TestHCaptchaVerifier(t *testing.T) {
+	url, received := siteverify(t, http.StatusOK, `{"success":true,"score":0.2,"score_reason":["safe"]}`)
+	v, err := NewVerifier(Config{Provider: ProviderHCaptcha, Secret: "s3cr3t", SiteKey: "site", Timeout: time.Second, VerifyURL: url})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := v.Verify(context.Background(), "token", "newsletter", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// hCaptcha reports risk, not legitimacy
+	if !result.Valid || result.Score != 0.8 || result.ActionAware || len(result.Reasons) != 1 {
+		t.Errorf("unexpected result %+v", result)
+	}
+	if received.PostForm.Get("sitekey") != "site" {
+		t.Errorf("site key is not sent: %v", received.PostForm)
+	}
+	if err = NewPolicy("newsletter", 0.5).Check(result, "newsletter"); err != nil {
+		t.Errorf("token without action is rejected: %v", err)
+	}
+}
+
+func TestTurnstileVerifier(t *testing.T) {
+	url, _ := siteverify(t, http.StatusOK, `{"success":false,"error-codes":["timeout-or-duplicate"]}`)
+	v, err := NewVerifier(Config{Provider: ProviderTurnstile, Secret: "s3cr3t", Timeout: time.Second, VerifyURL: url})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := v.Verify(context.Background(), "token", "newsletter", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Valid || len(result.Reasons) != 1 || result.Reasons[0] != "timeout-or-duplicate" {
+		t.Errorf("unexpected result %+v", result)
+	}
+
+	url, _ = siteverify(t, http.StatusOK, `{"success":true}`)
+	if result, err = NewTurnstileVerifier("s3cr3t", time.Second).withURL(url).Verify(context.Background(), "token", "", ""); err != nil {
+		t.Fatal(err)
+	}
+	if !result.Valid || result.Score != 1 {
+		t.Errorf("unexpected result of provider without scoring %+v", result)
+	}
+
+	url, _ = siteverify(t, http.StatusInternalServerError, ``)
+	if _, err = NewTurnstileVerifier("s3cr3t", time.Second).withURL(url).Verify(context.Background(), "token", "", ""); err == nil {
+		t.Error("expected error on failed siteverify call")
+	}
+}
+
+type fakeEnterprise struct {
+	recaptchapb.UnimplementedRecaptchaEnterpriseServiceServer
+	assessment *recaptchapb.Assessment
+	received   *recaptchapb.CreateAssessmentRequest
+	annotated  *recaptchapb.AnnotateAssessmentRequest
+	// hang makes assessments wait until the caller gives up
+	hang bool
+}
+
+func (f *fakeEnterprise) AnnotateAssessment(_ context.Context, rq *recaptchapb.AnnotateAssessmentRequest) (*recaptchapb.AnnotateAssessmentResponse, error) {
+	f.annotated = rq
+	return &recaptchapb.AnnotateAssessmentResponse{}, nil
+}
+
+func (f *fakeEnterprise) CreateAssessment(ctx context.Context, rq *recaptchapb.CreateAssessmentRequest) (*recaptchapb.Assessment, error) {
+	if f.hang {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	f.received = rq
+	return f.assessment, nil
+}
+
+// countingListener counts accepted connections
+type countingListener struct {
+	net.Listener
+	accepted atomic.Int32
+}
+
+func (l *countingListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err == nil {
+		l.accepted.Add(1)
+	}
+	return conn, err
+}
+
+// enterprise starts fake reCAPTCHA Enterprise service and creates verifier connected to it
+func enterprise(t *testing.T, timeout time.Duration) (*EnterpriseVerifier, *fakeEnterprise, *countingListener) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	counting := &countingListener{Listener: lis}
+	fake := &fakeEnterprise{}
+	srv := grpc.NewServer()
+	recaptchapb.RegisterRecaptchaEnterpriseServiceServer(srv, fake)
+	go func() { _ = srv.Serve(counting) }()
+	t.Cleanup(srv.Stop)
+
+	v, err := NewEnterpriseVerifier(context.Background(), "project", "site", timeout,
+		option.WithEndpoint(lis.Addr().String()),
+		option.WithoutAuthentication(),
+		option.WithGRPCDialOption(grpc.WithTransportCredentials(insecure.NewCredentials())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = v.Close() })
+	return v, fake, counting
+}
+
+func TestEnterpriseVerifier(t *testing.T) {
+	v, fake, _ := enterprise(t, time.Second)
+
+	fake.assessment = &recaptchapb.Assessment{
+		Name:            "projects/project/assessments/1",
+		TokenProperties: &recaptchapb.TokenProperties{Valid: true, Action: "newsletter"},
+		RiskAnalysis:    &recaptchapb.RiskAnalysis{Score: 0.9},
+	}
+	result, err := v.Verify(context.Background(), "token", "newsletter", "10.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Valid || result.Score != 0.9 || !result.ActionAware || result.AssessmentID != "projects/project/assessments/1" {
+		t.Errorf("unexpected result %+v", result)
+	}
+	event := fake.received.GetAssessment().GetEvent()
+	if fake.received.GetParent() != "projects/project" || event.GetSiteKey() != "site" ||
+		event.GetExpectedAction() != "newsletter" || event.GetUserIpAddress() != "10.0.0.1" {
+		t.Errorf("unexpected assessment request %v", fake.received)
+	}
+
+	// token without action does not pass the policy
+	fake.assessment.TokenProperties.Action = ""
+	if result, err = v.Verify(context.Background(), "token", "newsletter", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err = NewPolicy("newsletter", 0.5).Check(result, "newsletter"); err == nil {
+		t.Error("token without action is accepted")
+	}
+
+	fake.assessment.TokenProperties = &recaptchapb.TokenProperties{InvalidReason: recaptchapb.TokenProperties_EXPIRED}
+	if result, err = v.Verify(context.Background(), "token", "newsletter", ""); err != nil {
+		t.Fatal(err)
+	}
+	if result.Valid || len(result.Reasons) != 1 || result.Reasons[0] != "EXPIRED" {
+		t.Errorf("unexpected result of invalid token %+v", result)
+	}
+
+	if err = v.Annotate(context.Background(), "projects/project/assessments/1", true); err != nil {
+		t.Fatal(err)
+	}
+	if fake.annotated.GetName() != "projects/project/assessments/1" ||
+		fake.annotated.GetAnnotation() != recaptchapb.AnnotateAssessmentRequest_LEGITIMATE || len(fake.annotated.GetReasons()) != 0 {
+		t.Errorf("unexpected annotation %v", fake.annotated)
+	}
+}
+
+func TestEnterpriseVerifierReusesClient(t *testing.T) {
+	v, fake, lis := enterprise(t, time.Second)
+	fake.assessment = &recaptchapb.Assessment{
+		TokenProperties: &recaptchapb.TokenProperties{Valid: true, Action: "newsletter"},
+		RiskAnalysis:    &recaptchapb.RiskAnalysis{Score: 0.9},
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := v.Verify(context.Background(), "token", "newsletter", ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if accepted := lis.accepted.Load(); accepted != 1 {
+		t.Errorf("expected single connection, got %d", accepted)
+	}
+}
+
+func TestEnterpriseVerifierTimeout(t *testing.T) {
+	v, fake, _ := enterprise(t, 50*time.Millisecond)
+	fake.hang = true
+
+	started := time.Now()
+	_, err := v.Verify(context.Background(), "token", "newsletter", "")
+	if status.Code(errors.Unwrap(err)) != codes.DeadlineExceeded {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Errorf("assessment is not limited by timeout, took %s", elapsed)
+	}
+}
+
+func TestSiteverifyTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, rq *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	started := time.Now()
+	_, err := NewRecaptchaV3Verifier("s3cr3t", 50*time.Millisecond).withURL(srv.URL).Verify(context.Background(), "token", "", "")
+	if err == nil {
+		t.Error("expected timeout error")
+	}
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Errorf("verification is not limited by timeout, took %s", elapsed)
+	}
+}
@@ -0,0 +1,135 @@
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// Content encodings of the variants
+const (
+	Identity = "identity"
+	Gzip     = "gzip"
+	Brotli   = "br"
+)
+
+// Variants holds the body encoded with every supported content encoding
+type Variants struct {
+	identity []byte
+	gzip     []byte
+	brotli   []byte
+}
+
+// Encode compresses the body with gzip and brotli
+func Encode(body []byte) (*Variants, error) {
+	v := &Variants{identity: body}
+
+	var buf bytes.Buffer
+	gz, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = gz.Write(body); err != nil {
+		return nil, err
+	}
+	if err = gz.Close(); err != nil {
+		return nil, err
+	}
+	v.gzip = bytes.Clone(buf.Bytes())
+
+	buf.Reset()
+	br := brotli.NewWriterLevel(&buf, brotli.BestCompression)
+	if _, err = br.Write(body); err != nil {
+		return nil, err
+	}
+	if err = br.Close(); err != nil {
+		return nil, err
+	}
+	v.brotli = bytes.Clone(buf.Bytes())
+	return v, nil
+}
+
+// Negotiate picks the smallest variant acceptable by the client according to Accept-Encoding header
+func (v *Variants) Negotiate(acceptEncoding string) (string, []byte) {
+	accepted := parseAcceptEncoding(acceptEncoding)
+	switch {
+	case accepted[Brotli] && len(v.brotli) < len(v.identity):
+		return Brotli, v.brotli
+	case accepted[Gzip] && len(v.gzip) < len(v.identity):
+		return Gzip, v.gzip
+	default:
+		return Identity, v.identity
+	}
+}
+
+// Write writes variant negotiated with the client
+func (v *Variants) Write(w http.ResponseWriter, rq *http.Request, status int, contentType string) error {
+	encoding, body := v.Negotiate(rq.Header.Get("Accept-Encoding"))
+
+	h := w.Header()
+	if !varies(h, "Accept-Encoding") {
+		h.Add("Vary", "Accept-Encoding")
+	}
+	h.Set("Content-Type", contentType)
+	h.Set("Content-Length", strconv.Itoa(len(body)))
+	if encoding != Identity {
+		h.Set("Content-Encoding", encoding)
+	}
+	w.WriteHeader(status)
+	if rq.Method == http.MethodHead {
+		return nil
+	}
+	_, err := w.Write(body)
+	return err
+}
+
+// varies reports whether Vary header of the response already lists the request header
+func varies(h http.Header, header string) bool {
+	for _, value := range h.Values("Vary") {
+		for _, field := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(field), header) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseAcceptEncoding returns supported encodings accepted with non-zero quality.
+// Explicitly listed encodings take precedence over the "*" wildcard
+func parseAcceptEncoding(header string) map[string]bool {
+	explicit := map[string]bool{}
+	wildcard := false
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		q := 1.0
+		if qv, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(qv, 64); err == nil {
+				q = parsed
+			}
+		}
+		if name == "*" {
+			wildcard = q > 0
+			continue
+		}
+		explicit[name] = q > 0
+	}
+
+	accepted := map[string]bool{}
+	for _, encoding := range []string{Brotli, Gzip} {
+		if ok, listed := explicit[encoding]; listed {
+			accepted[encoding] = ok
+		} else {
+			accepted[encoding] = wildcard
+		}
+	}
+	return accepted
+}
@@ -0,0 +1,49 @@
+package compress
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	v, err := Encode(bytes.Repeat([]byte(`{"stars":42}`), 100))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		header   string
+		expected string
+	}{
+		{"", Identity},
+		{"gzip", Gzip},
+		{"gzip, deflate, br", Brotli},
+		{"br;q=0, gzip", Gzip},
+		{"*", Brotli},
+		{"br;q=0, *", Gzip},
+		{"gzip;q=0, br;q=0", Identity},
+	}
+	for _, c := range cases {
+		if encoding, _ := v.Negotiate(c.header); encoding != c.expected {
+			t.Errorf("%q: expected %s, got %s", c.header, c.expected, encoding)
+		}
+	}
+}
+
+func TestWriteKeepsVary(t *testing.T) {
+	v, err := Encode([]byte(`{"stars":42}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs := httptest.NewRecorder()
+	rs.Header().Add("Vary", "Origin")
+	rs.Header().Add("Vary", "Accept-Encoding")
+	if err = v.Write(rs, httptest.NewRequest(http.MethodGet, "/", nil), http.StatusOK, "application/json"); err != nil {
+		t.Fatal(err)
+	}
+	if vary := rs.Header().Values("Vary"); len(vary) != 2 {
+		t.Errorf("unexpected Vary %v", vary)
+	}
+}
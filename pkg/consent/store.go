@@ -0,0 +1,130 @@
+package consent
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/reportportal/landing-aggregator/pkg/audit"
+	"github.com/reportportal/landing-aggregator/pkg/email"
+)
+
+const (
+	typeConsent = "consent"
+	typeErasure = "erasure"
+)
+
+// Record is a proof of subscriber consent
+type Record struct {
+	Type     string `json:"type"`
+	ID       string `json:"id"`
+	Email    string `json:"email"`
+	ListID   string `json:"list_id"`
+	Provider string `json:"provider"`
+	// Status is a status the subscription was created with, pending for double opt-in
+	Status            string    `json:"status,omitempty"`
+	Timestamp         time.Time `json:"timestamp"`
+	IP                string    `json:"ip"`
+	UserAgent         string    `json:"user_agent"`
+	TextVersion       string    `json:"consent_text_version"`
+	CaptchaScore      *float32  `json:"captcha_score,omitempty"`
+	CaptchaAssessment string    `json:"captcha_assessment,omitempty"`
+}
+
+// erasure is a trace of erased records. Address is stored hashed only
+type erasure struct {
+	Type      string    `json:"type"`
+	EmailHash string    `json:"email_sha256"`
+	Timestamp time.Time `json:"timestamp"`
+	Count     int       `json:"count"`
+}
+
+// Store keeps consent records in an append-only audit log
+type Store struct {
+	log *audit.Log
+}
+
+// NewStore creates consent store on top of provided audit log
+func NewStore(log *audit.Log) *Store {
+	return &Store{log: log}
+}
+
+// Add appends consent record. ID, type and timestamp are populated if missing
+func (s *Store) Add(r *Record) error {
+	r.Type = typeConsent
+	if r.ID == "" {
+		r.ID = newID()
+	}
+	if r.Timestamp.IsZero() {
+		r.Timestamp = time.Now().UTC()
+	}
+	return s.log.Append(r)
+}
+
+// Find returns all consent records of provided address
+func (s *Store) Find(address string) ([]*Record, error) {
+	address = email.Normalize(address)
+	records := []*Record{}
+	err := s.log.Scan(func(raw json.RawMessage) error {
+		var r Record
+		if err := json.Unmarshal(raw, &r); err != nil || r.Type != typeConsent {
+			return nil
+		}
+		if sameAddress(r.Email, address) {
+			records = append(records, &r)
+		}
+		return nil
+	})
+	return records, err
+}
+
+// Erase removes all consent records of provided address and leaves a hashed trace of the erasure
+func (s *Store) Erase(address string) (int, error) {
+	address = email.Normalize(address)
+	count, err := s.log.Rewrite(func(raw json.RawMessage) bool {
+		var r Record
+		if err := json.Unmarshal(raw, &r); err != nil || r.Type != typeConsent {
+			return true
+		}
+		return !sameAddress(r.Email, address)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	hash := sha256.Sum256([]byte(strings.ToLower(address)))
+	return count, s.log.Append(&erasure{
+		Type:      typeErasure,
+		EmailHash: hex.EncodeToString(hash[:]),
+		Timestamp: time.Now().UTC(),
+		Count:     count,
+	})
+}
+
+// Latest returns the most recent consent record of the address in the list
+func (s *Store) Latest(address, listID string) (*Record, error) {
+	records, err := s.Find(address)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(records) - 1; i >= 0; i-- {
+		if records[i].ListID == listID {
+			return records[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// sameAddress compares stored address with the normalized one
+func sameAddress(stored, normalized string) bool {
+	return strings.EqualFold(email.Normalize(stored), normalized)
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
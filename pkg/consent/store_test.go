@@ -0,0 +1,114 @@
+package consent
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/reportportal/landing-aggregator/pkg/audit"
+)
+
+func newStore(t *testing.T) (*Store, *audit.Log) {
+	t.Helper()
+	log, err := audit.Open(filepath.Join(t.TempDir(), "consents.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = log.Close() })
+	return NewStore(log), log
+}
+
+func TestFind(t *testing.T) {
+	store, _ := newStore(t)
+	for _, r := range []*Record{
+		{Email: "john@example.com", ListID: "a", TextVersion: "v1"},
+		{Email: "jane@example.com", ListID: "a", TextVersion: "v1"},
+		{Email: " John@Example.com", ListID: "b", TextVersion: "v2"},
+	} {
+		if err := store.Add(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	records, err := store.Find("JOHN@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0].ListID != "a" || records[1].ListID != "b" {
+		t.Fatalf("unexpected records %+v", records)
+	}
+	if records[0].ID == "" || records[0].Timestamp.IsZero() || records[0].Type != typeConsent {
+		t.Errorf("record is not populated %+v", records[0])
+	}
+
+	latest, err := store.Latest("john@example.com", "b")
+	if err != nil || latest == nil || latest.TextVersion != "v2" {
+		t.Errorf("unexpected latest record %+v, %v", latest, err)
+	}
+}
+
+func TestErase(t *testing.T) {
+	store, log := newStore(t)
+	for _, email := range []string{"john@example.com", "jane@example.com", "John@example.com"} {
+		if err := store.Add(&Record{Email: email, ListID: "a"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	count, err := store.Erase("john@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("expected 2 erased records, got %d", count)
+	}
+	if records, _ := store.Find("john@example.com"); len(records) != 0 {
+		t.Errorf("records are not erased %+v", records)
+	}
+	if records, _ := store.Find("jane@example.com"); len(records) != 1 {
+		t.Errorf("records of other address are erased")
+	}
+
+	// address is kept hashed only
+	var traces []erasure
+	err = log.Scan(func(raw json.RawMessage) error {
+		if strings.Contains(strings.ToLower(string(raw)), "john@example.com") {
+			t.Errorf("erased address is left in the log: %s", raw)
+		}
+		var e erasure
+		if json.Unmarshal(raw, &e) == nil && e.Type == typeErasure {
+			traces = append(traces, e)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(traces) != 1 || traces[0].Count != 2 || traces[0].EmailHash == "" {
+		t.Errorf("unexpected erasure traces %+v", traces)
+	}
+
+	// new records are appended after the log is rewritten
+	if err = store.Add(&Record{Email: "john@example.com", ListID: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	if records, _ := store.Find("john@example.com"); len(records) != 1 {
+		t.Errorf("expected record added after erasure, got %d", len(records))
+	}
+}
+
+func TestInternationalizedAddress(t *testing.T) {
+	store, _ := newStore(t)
+	// subscriptions store the address with punycode domain
+	if err := store.Add(&Record{Email: "info@xn--bcher-kva.de", ListID: "a"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if records, err := store.Find(" info@Bücher.de "); err != nil || len(records) != 1 {
+		t.Errorf("record is not found by unicode domain: %+v, %v", records, err)
+	}
+	if count, err := store.Erase("info@bücher.de"); err != nil || count != 1 {
+		t.Errorf("expected 1 erased record, got %d, %v", count, err)
+	}
+}
@@ -0,0 +1,199 @@
+package cors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// methods checked against the router when policy does not list allowed methods
+var routeMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete,
+}
+
+// Policy describes cross-origin access to a group of routes
+type Policy struct {
+	// AllowedOrigins are exact origins (https://reportportal.io), wildcard subdomains (https://*.reportportal.io)
+	// or "*" for any origin. Empty list denies cross-origin access
+	AllowedOrigins []string
+	// AllowedMethods of preflight requests. Methods registered in the router for the path are allowed if empty
+	AllowedMethods []string
+	// AllowedHeaders are request headers allowed in addition to CORS-safelisted ones
+	AllowedHeaders []string
+	// ExposedHeaders are response headers readable by the client
+	ExposedHeaders []string
+	// AllowCredentials allows cookies and authorization headers. Cannot be combined with "*" origin
+	AllowCredentials bool
+	// MaxAge is how long preflight response may be cached
+	MaxAge time.Duration
+}
+
+func (p *Policy) validate() error {
+	for _, origin := range p.AllowedOrigins {
+		if origin == "*" {
+			if p.AllowCredentials {
+				return errors.New("credentials cannot be allowed for any origin")
+			}
+			continue
+		}
+		u, err := url.Parse(origin)
+		if err != nil || u.Scheme == "" || u.Host == "" || u.Path != "" {
+			return fmt.Errorf("invalid origin %q", origin)
+		}
+	}
+	return nil
+}
+
+// allowOrigin reports whether origin is allowed and returns value of the Access-Control-Allow-Origin header
+func (p *Policy) allowOrigin(origin string) (string, bool) {
+	for _, allowed := range p.AllowedOrigins {
+		switch {
+		case allowed == "*":
+			return "*", true
+		case strings.EqualFold(allowed, origin):
+			return origin, true
+		case matchWildcard(allowed, origin):
+			return origin, true
+		}
+	}
+	return "", false
+}
+
+// matchWildcard matches origin against pattern like https://*.example.com. The apex domain is not matched
+func matchWildcard(pattern, origin string) bool {
+	scheme, host, ok := strings.Cut(pattern, "://*.")
+	if !ok {
+		return false
+	}
+	originScheme, originHost, ok := strings.Cut(origin, "://")
+	if !ok || !strings.EqualFold(scheme, originScheme) {
+		return false
+	}
+	return len(originHost) > len(host)+1 && strings.HasSuffix(strings.ToLower(originHost), "."+strings.ToLower(host))
+}
+
+type routePolicy struct {
+	prefix string
+	policy *Policy
+}
+
+// CORS applies policies to the routes and answers preflight requests of every route
+type CORS struct {
+	routes   chi.Routes
+	def      *Policy
+	policies []routePolicy
+}
+
+// New creates CORS handler. Router is used to find methods allowed for preflight requests
+func New(routes chi.Routes, def Policy) (*CORS, error) {
+	if err := def.validate(); err != nil {
+		return nil, err
+	}
+	return &CORS{routes: routes, def: &def}, nil
+}
+
+// Route sets policy of the routes under path prefix. Prefix is matched on path segments,
+// so /subscriptions covers /subscriptions/confirm but not /subscriptions-export. The longest matching prefix wins
+func (c *CORS) Route(prefix string, p Policy) error {
+	if err := p.validate(); err != nil {
+		return fmt.Errorf("cors policy of %s: %w", prefix, err)
+	}
+	c.policies = append(c.policies, routePolicy{prefix: prefix, policy: &p})
+	sort.SliceStable(c.policies, func(i, j int) bool {
+		return len(c.policies[i].prefix) > len(c.policies[j].prefix)
+	})
+	return nil
+}
+
+// Handler is a middleware applying CORS policy of the request path
+func (c *CORS) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, rq *http.Request) {
+		origin := rq.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, rq)
+			return
+		}
+
+		w.Header().Add("Vary", "Origin")
+		policy := c.policy(rq.URL.Path)
+		allowOrigin, allowed := policy.allowOrigin(origin)
+
+		requestedMethod := rq.Header.Get("Access-Control-Request-Method")
+		if rq.Method == http.MethodOptions && requestedMethod != "" {
+			w.Header().Add("Vary", "Access-Control-Request-Method")
+			w.Header().Add("Vary", "Access-Control-Request-Headers")
+			methods := c.methods(policy, rq.URL.Path)
+			if allowed && contains(methods, requestedMethod) {
+				w.Header().Set("Access-Control-Allow-Origin", allowOrigin)
+				w.Header().Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+				if len(policy.AllowedHeaders) > 0 {
+					w.Header().Set("Access-Control-Allow-Headers", strings.Join(policy.AllowedHeaders, ", "))
+				}
+				if policy.AllowCredentials {
+					w.Header().Set("Access-Control-Allow-Credentials", "true")
+				}
+				if policy.MaxAge > 0 {
+					w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(policy.MaxAge.Seconds())))
+				}
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		if allowed {
+			w.Header().Set("Access-Control-Allow-Origin", allowOrigin)
+			if policy.AllowCredentials {
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			}
+			if len(policy.ExposedHeaders) > 0 {
+				w.Header().Set("Access-Control-Expose-Headers", strings.Join(policy.ExposedHeaders, ", "))
+			}
+		}
+		next.ServeHTTP(w, rq)
+	})
+}
+
+func (c *CORS) policy(path string) *Policy {
+	for _, rp := range c.policies {
+		if matchPrefix(rp.prefix, path) {
+			return rp.policy
+		}
+	}
+	return c.def
+}
+
+// matchPrefix reports whether path equals prefix or lies under it. Trailing slash of the prefix is ignored
+func matchPrefix(prefix, path string) bool {
+	prefix = strings.TrimSuffix(prefix, "/")
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+// methods returns methods allowed for the path by the policy or registered in the router
+func (c *CORS) methods(policy *Policy, path string) []string {
+	if len(policy.AllowedMethods) > 0 {
+		return policy.AllowedMethods
+	}
+	var methods []string
+	for _, method := range routeMethods {
+		if c.routes.Match(chi.NewRouteContext(), method, path) {
+			methods = append(methods, method)
+		}
+	}
+	return methods
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
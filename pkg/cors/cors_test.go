@@ -0,0 +1,95 @@
+package cors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestMatchWildcard(t *testing.T) {
+	cases := []struct {
+		origin  string
+		allowed bool
+	}{
+		{"https://docs.reportportal.io", true},
+		{"https://a.b.reportportal.io", true},
+		{"https://reportportal.io", false},
+		{"http://docs.reportportal.io", false},
+		{"https://evilreportportal.io", false},
+	}
+	for _, c := range cases {
+		if matchWildcard("https://*.reportportal.io", c.origin) != c.allowed {
+			t.Errorf("%s: expected allowed=%t", c.origin, c.allowed)
+		}
+	}
+}
+
+func TestMatchPrefix(t *testing.T) {
+	cases := []struct {
+		prefix, path string
+		matched      bool
+	}{
+		{"/subscriptions", "/subscriptions", true},
+		{"/subscriptions", "/subscriptions/confirm", true},
+		{"/subscriptions", "/subscriptions-export", false},
+		{"/admin/", "/admin", true},
+		{"/admin/", "/admin/review", true},
+		{"/admin/", "/administrator", false},
+		{"/", "/versions", true},
+	}
+	for _, c := range cases {
+		if matchPrefix(c.prefix, c.path) != c.matched {
+			t.Errorf("%s under %s: expected matched=%t", c.path, c.prefix, c.matched)
+		}
+	}
+}
+
+func TestPreflight(t *testing.T) {
+	router := chi.NewRouter()
+	c, err := New(router, Policy{AllowedOrigins: []string{"*"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = c.Route("/subscriptions", Policy{
+		AllowedOrigins:   []string{"https://*.reportportal.io"},
+		AllowedHeaders:   []string{"Content-Type"},
+		AllowCredentials: true,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	router.Use(c.Handler)
+	router.Get("/versions", func(w http.ResponseWriter, rq *http.Request) {})
+	router.Post("/subscriptions", func(w http.ResponseWriter, rq *http.Request) {})
+
+	cases := []struct {
+		path, origin, method string
+		allowOrigin          string
+	}{
+		{"/versions", "https://example.com", http.MethodGet, "*"},
+		{"/versions", "https://example.com", http.MethodPost, ""},
+		{"/subscriptions", "https://docs.reportportal.io", http.MethodPost, "https://docs.reportportal.io"},
+		{"/subscriptions", "https://example.com", http.MethodPost, ""},
+	}
+	for _, tc := range cases {
+		rq := httptest.NewRequest(http.MethodOptions, tc.path, nil)
+		rq.Header.Set("Origin", tc.origin)
+		rq.Header.Set("Access-Control-Request-Method", tc.method)
+		rs := httptest.NewRecorder()
+		router.ServeHTTP(rs, rq)
+
+		if rs.Code != http.StatusNoContent {
+			t.Errorf("%s %s: unexpected status %d", tc.method, tc.path, rs.Code)
+		}
+		if got := rs.Header().Get("Access-Control-Allow-Origin"); got != tc.allowOrigin {
+			t.Errorf("%s %s from %s: expected origin %q, got %q", tc.method, tc.path, tc.origin, tc.allowOrigin, got)
+		}
+	}
+}
+
+func TestCredentialsWithAnyOrigin(t *testing.T) {
+	if _, err := New(chi.NewRouter(), Policy{AllowedOrigins: []string{"*"}, AllowCredentials: true}); err == nil {
+		t.Error("credentials with any origin must be rejected")
+	}
+}
@@ -0,0 +1,60 @@
+package email
+
+// commonDomains are popular mailbox providers used to detect misspelled domains
+var commonDomains = []string{
+	"gmail.com",
+	"googlemail.com",
+	"yahoo.com",
+	"yahoo.co.uk",
+	"yahoo.fr",
+	"ymail.com",
+	"hotmail.com",
+	"hotmail.co.uk",
+	"hotmail.fr",
+	"outlook.com",
+	"live.com",
+	"msn.com",
+	"icloud.com",
+	"me.com",
+	"mac.com",
+	"aol.com",
+	"mail.com",
+	"email.com",
+	"gmx.com",
+	"gmx.de",
+	"gmx.net",
+	"web.de",
+	"yandex.ru",
+	"yandex.com",
+	"mail.ru",
+	"proton.me",
+	"protonmail.com",
+	"zoho.com",
+	"comcast.net",
+}
+
+// disposableDomains is a built-in blocklist of throwaway mailbox services.
+// It is intentionally short, full lists should be provided through a file
+var disposableDomains = []string{
+	"10minutemail.com",
+	"discard.email",
+	"dispostable.com",
+	"emailondeck.com",
+	"fakeinbox.com",
+	"getnada.com",
+	"guerrillamail.com",
+	"guerrillamail.net",
+	"maildrop.cc",
+	"mailinator.com",
+	"mailnesia.com",
+	"mintemail.com",
+	"mohmal.com",
+	"sharklasers.com",
+	"spamgourmet.com",
+	"temp-mail.org",
+	"tempmail.com",
+	"tempmailo.com",
+	"throwawaymail.com",
+	"trashmail.com",
+	"yopmail.com",
+}
@@ -0,0 +1,338 @@
+package email
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/net/idna"
+)
+
+const (
+	maxAddressLength = 254
+	maxLocalLength   = 64
+	maxLabelLength   = 63
+	maxTypoDistance  = 2
+)
+
+// Reason explains why address is rejected
+type Reason string
+
+const (
+	// ReasonSyntax means address does not conform to RFC 5322 addr-spec
+	ReasonSyntax Reason = "syntax"
+	// ReasonDisposable means address belongs to a disposable mailbox service
+	ReasonDisposable Reason = "disposable"
+	// ReasonTypo means domain looks like misspelled common domain
+	ReasonTypo Reason = "typo"
+	// ReasonNoMX means domain does not accept mail
+	ReasonNoMX Reason = "no_mx"
+)
+
+// Error describes rejected address
+type Error struct {
+	Reason     Reason
+	Message    string
+	Suggestion string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Resolver looks up DNS records of a domain. *net.Resolver satisfies this interface
+type Resolver interface {
+	LookupMX(ctx context.Context, name string) ([]*net.MX, error)
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// Validator checks and normalizes email addresses
+type Validator struct {
+	disposable    map[string]struct{}
+	commonDomains []string
+	resolver      Resolver
+	lookupTimeout time.Duration
+}
+
+// Option configures Validator
+type Option func(*Validator)
+
+// WithDisposableDomains adds domains to the disposable domains blocklist
+func WithDisposableDomains(domains ...string) Option {
+	return func(v *Validator) {
+		for _, d := range domains {
+			if d = normalizeDomain(d); d != "" {
+				v.disposable[d] = struct{}{}
+			}
+		}
+	}
+}
+
+// WithCommonDomains replaces list of domains used for typo suggestions. Empty list disables suggestions
+func WithCommonDomains(domains ...string) Option {
+	return func(v *Validator) {
+		v.commonDomains = domains
+	}
+}
+
+// WithResolver enables MX lookup with provided resolver and per-lookup timeout
+func WithResolver(r Resolver, timeout time.Duration) Option {
+	return func(v *Validator) {
+		v.resolver = r
+		v.lookupTimeout = timeout
+	}
+}
+
+// NewValidator creates new validator with built-in disposable and common domain lists. MX lookup is disabled by default
+func NewValidator(opts ...Option) *Validator {
+	v := &Validator{
+		disposable:    map[string]struct{}{},
+		commonDomains: commonDomains,
+	}
+	WithDisposableDomains(disposableDomains...)(v)
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
+}
+
+// LoadDomains reads domains list from a file, one domain per line. Empty lines and lines starting with '#' are skipped
+func LoadDomains(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var domains []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		domains = append(domains, line)
+	}
+	return domains, scanner.Err()
+}
+
+// Validate checks provided address and returns its normalized form:
+// domain is lowercased and converted to ASCII (punycode), local part is kept as is
+func (v *Validator) Validate(ctx context.Context, address string) (string, error) {
+	local, domain, err := parse(address)
+	if err != nil {
+		return "", err
+	}
+	normalized := local + "@" + domain
+
+	if v.isDisposable(domain) {
+		return "", &Error{Reason: ReasonDisposable, Message: "disposable email addresses are not allowed"}
+	}
+
+	if suggestion := v.suggestDomain(domain); suggestion != "" {
+		return "", &Error{
+			Reason:     ReasonTypo,
+			Message:    fmt.Sprintf("did you mean %s@%s?", local, suggestion),
+			Suggestion: local + "@" + suggestion,
+		}
+	}
+
+	if v.resolver != nil {
+		if err = v.checkMX(ctx, domain); err != nil {
+			return "", err
+		}
+	}
+	return normalized, nil
+}
+
+// Normalize returns the address in the form produced by Validate without checking it against
+// blocklists or DNS, so that stored addresses can be looked up by user input.
+// Addresses which cannot be parsed are returned trimmed
+func Normalize(address string) string {
+	address = strings.TrimSpace(address)
+	local, domain, err := parse(address)
+	if err != nil {
+		return address
+	}
+	return local + "@" + domain
+}
+
+// parse validates addr-spec syntax and returns local part and normalized domain
+func parse(address string) (string, string, error) {
+	syntaxErr := &Error{Reason: ReasonSyntax, Message: "invalid email address"}
+
+	if address == "" || len(address) > maxAddressLength || strings.TrimSpace(address) != address {
+		return "", "", syntaxErr
+	}
+
+	// reject display names, angle brackets and comments, only bare addr-spec is expected
+	parsed, err := mail.ParseAddress(address)
+	if err != nil || parsed.Name != "" || strings.ContainsAny(address, "<>()") {
+		return "", "", syntaxErr
+	}
+
+	// parser unquotes local part, so raw input is used to keep the address deliverable
+	at := strings.LastIndex(address, "@")
+	local, domain := address[:at], address[at+1:]
+	if len(local) > maxLocalLength {
+		return "", "", syntaxErr
+	}
+
+	// domain literals like [127.0.0.1] are valid per RFC but never used by real subscribers
+	if strings.HasPrefix(domain, "[") {
+		return "", "", syntaxErr
+	}
+
+	domain, err = idna.Lookup.ToASCII(strings.ToLower(domain))
+	if err != nil || !strings.Contains(domain, ".") {
+		return "", "", syntaxErr
+	}
+	for _, label := range strings.Split(domain, ".") {
+		if label == "" || len(label) > maxLabelLength {
+			return "", "", syntaxErr
+		}
+	}
+	if tld := domain[strings.LastIndex(domain, ".")+1:]; len(tld) < 2 || strings.Trim(tld, "0123456789") == "" {
+		return "", "", syntaxErr
+	}
+
+	if len(local)+1+len(domain) > maxAddressLength {
+		return "", "", syntaxErr
+	}
+	return local, domain, nil
+}
+
+// isDisposable checks the domain and all its parent domains against the blocklist
+func (v *Validator) isDisposable(domain string) bool {
+	for d := domain; strings.Contains(d, "."); d = d[strings.Index(d, ".")+1:] {
+		if _, ok := v.disposable[d]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// suggestDomain returns common domain closest to provided one or empty string if domain is known or not similar to any.
+// Name part and TLD are compared separately to avoid suggesting gmx.de for a legit gmx.at
+func (v *Validator) suggestDomain(domain string) string {
+	name, tld := splitDomain(domain)
+
+	suggestion := ""
+	best := maxTypoDistance + 1
+	for _, common := range v.commonDomains {
+		if common == domain {
+			return ""
+		}
+		commonName, commonTLD := splitDomain(common)
+
+		nameDistance := distance(name, commonName)
+		tldDistance := distance(tld, commonTLD)
+		if nameDistance > nameTypoLimit(commonName) || tldDistance > 1 {
+			continue
+		}
+		if d := nameDistance + tldDistance; d < best {
+			best = d
+			suggestion = common
+		}
+	}
+	return suggestion
+}
+
+// nameTypoLimit returns number of edits which is still considered as a typo. Short names are not checked
+func nameTypoLimit(name string) int {
+	switch {
+	case len(name) < 4:
+		return 0
+	case len(name) < 6:
+		return 1
+	default:
+		return maxTypoDistance
+	}
+}
+
+// splitDomain splits domain into the first label and the rest of it
+func splitDomain(domain string) (string, string) {
+	if i := strings.Index(domain, "."); i >= 0 {
+		return domain[:i], domain[i+1:]
+	}
+	return domain, ""
+}
+
+func (v *Validator) checkMX(ctx context.Context, domain string) error {
+	if v.lookupTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, v.lookupTimeout)
+		defer cancel()
+	}
+
+	noMX := &Error{Reason: ReasonNoMX, Message: "email domain does not accept mail"}
+
+	records, err := v.resolver.LookupMX(ctx, domain)
+	if err == nil && len(records) > 0 {
+		// RFC 7505 null MX explicitly declares that domain accepts no mail
+		if len(records) == 1 && (records[0].Host == "." || records[0].Host == "") {
+			return noMX
+		}
+		return nil
+	}
+	if err != nil && !isNotFound(err) {
+		// do not reject addresses because of DNS hiccups
+		return nil
+	}
+
+	// RFC 5321 implicit MX: domain without MX records still accepts mail on its A/AAAA records
+	hosts, err := v.resolver.LookupHost(ctx, domain)
+	if err != nil && !isNotFound(err) {
+		return nil
+	}
+	if len(hosts) == 0 {
+		return noMX
+	}
+	return nil
+}
+
+func isNotFound(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
+
+// distance calculates optimal string alignment distance: Levenshtein distance with adjacent transpositions
+func distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	rows := make([][]int, len(ra)+1)
+	for i := range rows {
+		rows[i] = make([]int, len(rb)+1)
+		rows[i][0] = i
+	}
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			rows[i][j] = min(rows[i-1][j]+1, rows[i][j-1]+1, rows[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				rows[i][j] = min(rows[i][j], rows[i-2][j-2]+1)
+			}
+		}
+	}
+	return rows[len(ra)][len(rb)]
+}
+
+func normalizeDomain(domain string) string {
+	domain = strings.TrimSpace(strings.ToLower(domain))
+	if ascii, err := idna.Lookup.ToASCII(domain); err == nil {
+		return ascii
+	}
+	return domain
+}
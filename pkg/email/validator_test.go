@@ -0,0 +1,151 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+)
+
+type fakeResolver struct {
+	mx    map[string][]*net.MX
+	hosts map[string][]string
+}
+
+func (r *fakeResolver) LookupMX(_ context.Context, name string) ([]*net.MX, error) {
+	if records, ok := r.mx[name]; ok {
+		return records, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+}
+
+func (r *fakeResolver) LookupHost(_ context.Context, host string) ([]string, error) {
+	if hosts, ok := r.hosts[host]; ok {
+		return hosts, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+}
+
+func TestValidateSyntax(t *testing.T) {
+	v := NewValidator()
+	cases := map[string]string{
+		"john.doe@example.com":         "john.doe@example.com",
+		"John+news@Example.COM":        "John+news@example.com",
+		"user@sub.example.technology":  "user@sub.example.technology",
+		"o'brien@example.ie":           "o'brien@example.ie",
+		"info@bücher.de":               "info@xn--bcher-kva.de",
+		"\"quoted local\"@example.com": "\"quoted local\"@example.com",
+	}
+	for address, expected := range cases {
+		normalized, err := v.Validate(context.Background(), address)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", address, err)
+			continue
+		}
+		if normalized != expected {
+			t.Errorf("%s: expected %s, got %s", address, expected, normalized)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"plainaddress",
+		"@example.com",
+		"john@",
+		"john@localhost",
+		"john@example..com",
+		"John <john@example.com>",
+		" john@example.com",
+		"john@[127.0.0.1]",
+		"john@example.1",
+		"very.very.very.very.very.very.very.very.very.very.long.local.part.over64@example.com",
+	}
+	for _, address := range invalid {
+		_, err := v.Validate(context.Background(), address)
+		assertReason(t, address, err, ReasonSyntax)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	cases := map[string]string{
+		"info@Bücher.de":            "info@xn--bcher-kva.de",
+		" John@Example.COM ":        "John@example.com",
+		"info@xn--bcher-kva.de":     "info@xn--bcher-kva.de",
+		"not an address":            "not an address",
+		"disposable@mailinator.com": "disposable@mailinator.com",
+	}
+	for address, expected := range cases {
+		if normalized := Normalize(address); normalized != expected {
+			t.Errorf("%q: expected %s, got %s", address, expected, normalized)
+		}
+	}
+}
+
+func TestValidateDisposable(t *testing.T) {
+	v := NewValidator(WithDisposableDomains("Throwaway.Example"))
+
+	for _, address := range []string{"bot@mailinator.com", "bot@inbox.mailinator.com", "bot@throwaway.example"} {
+		_, err := v.Validate(context.Background(), address)
+		assertReason(t, address, err, ReasonDisposable)
+	}
+}
+
+func TestValidateTypo(t *testing.T) {
+	v := NewValidator()
+
+	suggestions := map[string]string{
+		"john@gmial.com":   "john@gmail.com",
+		"john@gmail.con":   "john@gmail.com",
+		"john@hotmial.com": "john@hotmail.com",
+		"john@yahooo.com":  "john@yahoo.com",
+	}
+	for address, expected := range suggestions {
+		_, err := v.Validate(context.Background(), address)
+		assertReason(t, address, err, ReasonTypo)
+		var emailErr *Error
+		if errors.As(err, &emailErr) && emailErr.Suggestion != expected {
+			t.Errorf("%s: expected suggestion %s, got %s", address, expected, emailErr.Suggestion)
+		}
+	}
+
+	for _, address := range []string{"john@gmx.at", "john@mail.com", "john@epam.com", "john@yahoo.de"} {
+		if _, err := v.Validate(context.Background(), address); err != nil {
+			t.Errorf("%s: unexpected error %v", address, err)
+		}
+	}
+}
+
+func TestValidateMX(t *testing.T) {
+	resolver := &fakeResolver{
+		mx: map[string][]*net.MX{
+			"example.com": {{Host: "mx.example.com.", Pref: 10}},
+			"nullmx.com":  {{Host: ".", Pref: 0}},
+		},
+		hosts: map[string][]string{
+			"implicit.com": {"192.0.2.1"},
+		},
+	}
+	v := NewValidator(WithResolver(resolver, 0))
+
+	for _, address := range []string{"john@example.com", "john@implicit.com"} {
+		if _, err := v.Validate(context.Background(), address); err != nil {
+			t.Errorf("%s: unexpected error %v", address, err)
+		}
+	}
+	for _, address := range []string{"john@nullmx.com", "john@nowhere.com"} {
+		_, err := v.Validate(context.Background(), address)
+		assertReason(t, address, err, ReasonNoMX)
+	}
+}
+
+func assertReason(t *testing.T, address string, err error, reason Reason) {
+	t.Helper()
+	var emailErr *Error
+	if !errors.As(err, &emailErr) {
+		t.Errorf("%s: expected %s error, got %v", address, reason, err)
+		return
+	}
+	if emailErr.Reason != reason {
+		t.Errorf("%s: expected %s error, got %s", address, reason, emailErr.Reason)
+	}
+}
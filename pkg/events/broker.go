@@ -0,0 +1,203 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/reportportal/landing-aggregator/buf"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// TypeReset tells client that missed events are not available anymore and state has to be reloaded
+	TypeReset = "reset"
+
+	subscriberBuffer  = 16
+	heartbeatInterval = 25 * time.Second
+	retryInterval     = 5 * time.Second
+)
+
+// Event is a typed update pushed to subscribers
+type Event struct {
+	ID   uint64
+	Type string
+	Data json.RawMessage
+}
+
+// Broker publishes events to Server-Sent Events subscribers and keeps bounded history of them,
+// so that reconnecting clients can resume from the last received event
+type Broker struct {
+	// epoch distinguishes IDs of events published by different runs of the service
+	epoch   string
+	history *buf.RingBuffer[Event]
+
+	// maxSubscribers limits concurrent streams, unlimited if zero
+	maxSubscribers int
+
+	mu          sync.Mutex
+	lastID      uint64
+	subscribers map[chan Event]struct{}
+}
+
+// NewBroker creates broker keeping historySize latest events and serving up to maxSubscribers streams at once.
+// Zero maxSubscribers does not limit streams
+func NewBroker(historySize, maxSubscribers int) *Broker {
+	return &Broker{
+		epoch:          strconv.FormatInt(time.Now().Unix(), 36),
+		history:        buf.New[Event](historySize),
+		maxSubscribers: maxSubscribers,
+		subscribers:    map[chan Event]struct{}{},
+	}
+}
+
+// Publish sends event to all subscribers. Subscribers which cannot keep up are disconnected
+// and resume from history once reconnected
+func (b *Broker) Publish(eventType string, data interface{}) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastID++
+	event := Event{ID: b.lastID, Type: eventType, Data: raw}
+	b.history.Add(event)
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+	return nil
+}
+
+// Subscribers returns number of connected subscribers
+func (b *Broker) Subscribers() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subscribers)
+}
+
+// ServeHTTP streams events. Last-Event-ID header or lastEventId parameter resumes the stream.
+// 503 is returned once the limit of subscribers is reached
+func (b *Broker) ServeHTTP(w http.ResponseWriter, rq *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	lastEventID := rq.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = rq.URL.Query().Get("lastEventId")
+	}
+	ch, missed, ok := b.subscribe(lastEventID)
+	if !ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(retryInterval.Seconds())))
+		http.Error(w, "too many subscribers", http.StatusServiceUnavailable)
+		return
+	}
+	defer b.unsubscribe(ch)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	// disables response buffering of nginx
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", retryInterval.Milliseconds())
+	for _, event := range missed {
+		b.write(w, event)
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-rq.Context().Done():
+			return
+		case event, ok := <-ch:
+			if !ok {
+				return
+			}
+			b.write(w, event)
+			flusher.Flush()
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// subscribe registers subscriber and returns events published after the last received one.
+// Reset event is returned when some of them are not in the history anymore.
+// False is returned if the limit of subscribers is reached
+func (b *Broker) subscribe(lastEventID string) (chan Event, []Event, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.maxSubscribers > 0 && len(b.subscribers) >= b.maxSubscribers {
+		return nil, nil, false
+	}
+	ch := make(chan Event, subscriberBuffer)
+	b.subscribers[ch] = struct{}{}
+	if lastEventID == "" {
+		return ch, nil, true
+	}
+
+	lastID, ok := b.parseID(lastEventID)
+	if !ok || lastID > b.lastID {
+		return ch, []Event{b.reset()}, true
+	}
+	missed := []Event{}
+	b.history.Do(func(event Event) {
+		if event.ID > lastID {
+			missed = append(missed, event)
+		}
+	})
+	if lastID < b.lastID && (len(missed) == 0 || missed[0].ID != lastID+1) {
+		return ch, []Event{b.reset()}, true
+	}
+	return ch, missed, true
+}
+
+func (b *Broker) unsubscribe(ch chan Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subscribers[ch]; ok {
+		delete(b.subscribers, ch)
+		close(ch)
+	}
+}
+
+// reset builds reset event pointing at the latest published event. Must be called under lock
+func (b *Broker) reset() Event {
+	return Event{ID: b.lastID, Type: TypeReset, Data: json.RawMessage("{}")}
+}
+
+func (b *Broker) write(w http.ResponseWriter, event Event) {
+	if _, err := fmt.Fprintf(w, "id: %s-%d\nevent: %s\ndata: %s\n\n", b.epoch, event.ID, event.Type, event.Data); err != nil {
+		log.Debugf("Cannot write event: %v", err)
+	}
+}
+
+// parseID parses event ID published by this run of the service
+func (b *Broker) parseID(id string) (uint64, bool) {
+	epoch, seq, ok := strings.Cut(id, "-")
+	if !ok || epoch != b.epoch {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(seq, 10, 64)
+	return n, err == nil
+}
@@ -0,0 +1,127 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestBrokerResume(t *testing.T) {
+	broker := NewBroker(3, 0)
+	for i := 1; i <= 5; i++ {
+		if err := broker.Publish("release", map[string]int{"n": i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	id := func(n int) string {
+		return broker.epoch + "-" + strconv.Itoa(n)
+	}
+
+	ch, missed, _ := broker.subscribe(id(3))
+	if len(missed) != 2 || missed[0].ID != 4 || missed[1].ID != 5 {
+		t.Errorf("unexpected missed events %+v", missed)
+	}
+	broker.unsubscribe(ch)
+
+	ch, missed, _ = broker.subscribe(id(5))
+	if len(missed) != 0 {
+		t.Errorf("unexpected missed events %+v", missed)
+	}
+	broker.unsubscribe(ch)
+
+	for _, lastID := range []string{id(1), id(9), "old-3", "garbage"} {
+		ch, missed, _ = broker.subscribe(lastID)
+		if len(missed) != 1 || missed[0].Type != TypeReset {
+			t.Errorf("%s: expected reset, got %+v", lastID, missed)
+		}
+		broker.unsubscribe(ch)
+	}
+	if broker.Subscribers() != 0 {
+		t.Errorf("expected no subscribers, got %d", broker.Subscribers())
+	}
+}
+
+func TestBrokerDropsSlowSubscribers(t *testing.T) {
+	broker := NewBroker(10, 0)
+	ch, _, _ := broker.subscribe("")
+	for i := 0; i <= subscriberBuffer; i++ {
+		_ = broker.Publish("stars", i)
+	}
+	if broker.Subscribers() != 0 {
+		t.Error("slow subscriber should be dropped")
+	}
+	n := 0
+	for range ch {
+		n++
+	}
+	if n != subscriberBuffer {
+		t.Errorf("expected %d buffered events, got %d", subscriberBuffer, n)
+	}
+}
+
+func TestBrokerLimitsSubscribers(t *testing.T) {
+	broker := NewBroker(10, 1)
+	ch, _, ok := broker.subscribe("")
+	if !ok {
+		t.Fatal("first subscriber must be accepted")
+	}
+
+	rs := httptest.NewRecorder()
+	broker.ServeHTTP(rs, httptest.NewRequest(http.MethodGet, "/events", nil))
+	if rs.Code != http.StatusServiceUnavailable || rs.Header().Get("Retry-After") != "5" {
+		t.Errorf("unexpected response %d, Retry-After %q", rs.Code, rs.Header().Get("Retry-After"))
+	}
+
+	broker.unsubscribe(ch)
+	if _, _, ok = broker.subscribe(""); !ok {
+		t.Error("subscriber must be accepted once another one leaves")
+	}
+}
+
+func TestBrokerStream(t *testing.T) {
+	broker := NewBroker(10, 0)
+	_ = broker.Publish("release", map[string]string{"version": "5.0"})
+
+	srv := httptest.NewServer(broker)
+	defer srv.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	rq, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	rq.Header.Set("Last-Event-ID", broker.epoch+"-0")
+	rs, err := http.DefaultClient.Do(rq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rs.Body.Close()
+	if ct := rs.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("unexpected content type %q", ct)
+	}
+
+	lines := []string{}
+	scanner := bufio.NewScanner(rs.Body)
+	for len(lines) < 5 && scanner.Scan() {
+		if scanner.Text() == "" {
+			continue
+		}
+		lines = append(lines, scanner.Text())
+		// history is replayed, the next event is pushed live
+		if len(lines) == 4 {
+			_ = broker.Publish("stars", map[string]int{"total": 10})
+		}
+	}
+	expected := []string{
+		"retry: 5000",
+		"id: " + broker.epoch + "-1",
+		"event: release",
+		`data: {"version":"5.0"}`,
+		"id: " + broker.epoch + "-2",
+	}
+	if strings.Join(lines, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected stream:\n%s", strings.Join(lines, "\n"))
+	}
+}
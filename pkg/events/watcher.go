@@ -0,0 +1,71 @@
+package events
+
+import (
+	"time"
+
+	"github.com/reportportal/landing-aggregator/pkg/snapshot"
+	log "github.com/sirupsen/logrus"
+)
+
+// Diff returns events data describing change between snapshots. Nothing is published when it returns nil
+type Diff func(prev, next *snapshot.Snapshot) []interface{}
+
+type source struct {
+	eventType string
+	load      func() *snapshot.Snapshot
+	diff      Diff
+	version   string
+}
+
+// Watcher polls snapshots of the sources and publishes typed events when their versions change
+type Watcher struct {
+	broker  *Broker
+	sources []*source
+}
+
+// NewWatcher creates watcher publishing events to the broker
+func NewWatcher(broker *Broker) *Watcher {
+	return &Watcher{broker: broker}
+}
+
+// Watch registers source. Changes made before the source is refreshed for the first time are not published
+func (w *Watcher) Watch(eventType string, load func() *snapshot.Snapshot, diff Diff) {
+	w.sources = append(w.sources, &source{eventType: eventType, load: load, diff: diff})
+}
+
+// Start polls the sources every interval. Initial snapshots are loaded in background,
+// so that slow sources do not delay the startup
+func (w *Watcher) Start(interval time.Duration) {
+	go func() {
+		prev := make([]*snapshot.Snapshot, len(w.sources))
+		for i, src := range w.sources {
+			prev[i] = src.load()
+		}
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			for i, src := range w.sources {
+				prev[i] = w.check(src, prev[i])
+			}
+		}
+	}()
+}
+
+// check publishes events if snapshot has changed and returns the current one
+func (w *Watcher) check(src *source, prev *snapshot.Snapshot) *snapshot.Snapshot {
+	next := src.load()
+	if next.Version == prev.Version {
+		return prev
+	}
+	// initial data of the source is not an update
+	if prev.Refreshed.IsZero() {
+		return next
+	}
+	for _, data := range src.diff(prev, next) {
+		if err := w.broker.Publish(src.eventType, data); err != nil {
+			log.Errorf("Cannot publish %s event: %v", src.eventType, err)
+		}
+	}
+	return next
+}
@@ -0,0 +1,48 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	"github.com/reportportal/landing-aggregator/pkg/snapshot"
+)
+
+func TestWatcherCheck(t *testing.T) {
+	broker := NewBroker(10, 0)
+	watcher := NewWatcher(broker)
+	store := snapshot.NewStore(time.Minute, 0)
+	var diffs int
+	watcher.Watch("stars", store.Load, func(prev, next *snapshot.Snapshot) []interface{} {
+		diffs++
+		return []interface{}{map[string]int{"previous": prev.Data.(int), "total": next.Data.(int)}}
+	})
+	src := watcher.sources[0]
+
+	prev := store.Load()
+	if next := watcher.check(src, prev); next != prev {
+		t.Error("unchanged snapshot must be kept")
+	}
+
+	store.Set(1)
+	prev = watcher.check(src, prev)
+	if diffs != 0 || prev.Data != 1 {
+		t.Errorf("initial data must not be published, %d diffs", diffs)
+	}
+
+	store.Touch()
+	prev = watcher.check(src, prev)
+	if diffs != 0 {
+		t.Error("refresh without changes must not be published")
+	}
+
+	store.Set(2)
+	prev = watcher.check(src, prev)
+	if diffs != 1 || prev.Data != 2 {
+		t.Errorf("change must be published once, %d diffs", diffs)
+	}
+	ch, missed, _ := broker.subscribe(broker.epoch + "-0")
+	defer broker.unsubscribe(ch)
+	if len(missed) != 1 || missed[0].Type != "stars" || string(missed[0].Data) != `{"previous":1,"total":2}` {
+		t.Errorf("unexpected events %+v", missed)
+	}
+}
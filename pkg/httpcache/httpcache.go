@@ -0,0 +1,80 @@
+package httpcache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/reportportal/landing-aggregator/pkg/snapshot"
+)
+
+// Check sets ETag, Last-Modified and Cache-Control headers of the response composed from provided snapshots.
+// ETag covers snapshot versions and query parameters, so different counts or JSONP callbacks are cached separately.
+// If client copy is still valid, 304 is written and true is returned
+func Check(w http.ResponseWriter, rq *http.Request, snapshots ...*snapshot.Snapshot) bool {
+	hash := sha256.New()
+	hash.Write([]byte(rq.URL.Query().Encode()))
+
+	var modified time.Time
+	fresh := time.Duration(-1)
+	var period time.Duration
+	for _, s := range snapshots {
+		fmt.Fprintf(hash, "|%s", s.Version)
+		if s.Modified.After(modified) {
+			modified = s.Modified
+		}
+		if s.Static() {
+			continue
+		}
+		if f := s.Fresh(); fresh < 0 || f < fresh {
+			fresh = f
+		}
+		if s.Period > period {
+			period = s.Period
+		}
+	}
+	if fresh < 0 {
+		fresh = 0
+	}
+
+	etag := `W/"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`
+	// 304 must carry the same Vary as the compressed response it validates
+	w.Header().Add("Vary", "Accept-Encoding")
+	w.Header().Set("ETag", etag)
+	if !modified.IsZero() {
+		w.Header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d, stale-while-revalidate=%d",
+		int(fresh.Seconds()), int(period.Seconds())))
+
+	if notModified(rq, etag, modified) {
+		w.WriteHeader(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+// notModified evaluates If-None-Match and, in its absence, If-Modified-Since preconditions
+func notModified(rq *http.Request, etag string, modified time.Time) bool {
+	if rq.Method != http.MethodGet && rq.Method != http.MethodHead {
+		return false
+	}
+	if inm := rq.Header.Get("If-None-Match"); inm != "" {
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+	if ims := rq.Header.Get("If-Modified-Since"); ims != "" && !modified.IsZero() {
+		if t, err := http.ParseTime(ims); err == nil {
+			return !modified.Truncate(time.Second).After(t)
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package httpcache
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/reportportal/landing-aggregator/pkg/snapshot"
+)
+
+func TestCheck(t *testing.T) {
+	store := snapshot.NewStore(time.Hour, []string{"v1"})
+	store.Set([]string{"v1"})
+
+	rs := httptest.NewRecorder()
+	if Check(rs, httptest.NewRequest(http.MethodGet, "/versions", nil), store.Load()) {
+		t.Fatal("unconditional request must not be answered with 304")
+	}
+	etag := rs.Header().Get("ETag")
+	lastModified := rs.Header().Get("Last-Modified")
+	if etag == "" || lastModified == "" {
+		t.Fatal("validators must be set")
+	}
+	if cc := rs.Header().Get("Cache-Control"); cc != "public, max-age=3599, stale-while-revalidate=3600" {
+		t.Errorf("unexpected Cache-Control %q", cc)
+	}
+
+	cases := []struct {
+		name    string
+		url     string
+		header  string
+		value   string
+		matched bool
+	}{
+		{"same etag", "/versions", "If-None-Match", etag, true},
+		{"etag list", "/versions", "If-None-Match", `"other", ` + etag, true},
+		{"other query", "/versions?jsonp=cb", "If-None-Match", etag, false},
+		{"not modified since", "/versions", "If-Modified-Since", lastModified, true},
+		{"modified since", "/versions", "If-Modified-Since", time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), false},
+	}
+	for _, c := range cases {
+		rq := httptest.NewRequest(http.MethodGet, c.url, nil)
+		rq.Header.Set(c.header, c.value)
+		rs := httptest.NewRecorder()
+		if Check(rs, rq, store.Load()) != c.matched {
+			t.Errorf("%s: expected matched=%t", c.name, c.matched)
+		}
+		if vary := rs.Header().Get("Vary"); vary != "Accept-Encoding" {
+			t.Errorf("%s: unexpected Vary %q", c.name, vary)
+		}
+	}
+
+	store.Set([]string{"v1"})
+	rq := httptest.NewRequest(http.MethodGet, "/versions", nil)
+	rq.Header.Set("If-None-Match", etag)
+	if !Check(httptest.NewRecorder(), rq, store.Load()) {
+		t.Error("refresh with the same data must keep etag")
+	}
+
+	store.Set([]string{"v2"})
+	if Check(httptest.NewRecorder(), rq, store.Load()) {
+		t.Error("changed data must change etag")
+	}
+}
@@ -0,0 +1,156 @@
+package imgproxy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DiskCache keeps images in a directory limited by total size.
+// Least recently used images are evicted once the limit is exceeded
+type DiskCache struct {
+	dir      string
+	maxBytes int64
+
+	mu      sync.Mutex
+	size    int64
+	entries map[string]*entry
+	now     func() time.Time
+}
+
+type entry struct {
+	size int64
+	used time.Time
+}
+
+// NewDiskCache creates cache in the directory. Images left by previous runs are reused
+func NewDiskCache(dir string, maxBytes int64) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+	c := &DiskCache{dir: dir, maxBytes: maxBytes, entries: map[string]*entry{}, now: time.Now}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		info, err := f.Info()
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		c.entries[f.Name()] = &entry{size: info.Size(), used: info.ModTime()}
+		c.size += info.Size()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.evict()
+	return c, nil
+}
+
+// Get returns cached image by key
+func (c *DiskCache) Get(key string) ([]byte, bool) {
+	name := fileName(key)
+
+	c.mu.Lock()
+	e, ok := c.entries[name]
+	if ok {
+		e.used = c.now()
+	}
+	c.mu.Unlock()
+	if !ok {
+		return nil, false
+	}
+
+	data, err := os.ReadFile(filepath.Join(c.dir, name))
+	if err != nil {
+		c.remove(name)
+		return nil, false
+	}
+	return data, true
+}
+
+// Put stores image by key evicting least recently used images if needed
+func (c *DiskCache) Put(key string, data []byte) error {
+	if int64(len(data)) > c.maxBytes {
+		return nil
+	}
+	name := fileName(key)
+
+	// write to temporary file first, so that readers never see partial image
+	tmp, err := os.CreateTemp(c.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := os.Rename(tmp.Name(), filepath.Join(c.dir, name)); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if e, ok := c.entries[name]; ok {
+		c.size -= e.size
+	}
+	c.entries[name] = &entry{size: int64(len(data)), used: c.now()}
+	c.size += int64(len(data))
+	c.evict()
+	return nil
+}
+
+// Size returns total size of cached images
+func (c *DiskCache) Size() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.size
+}
+
+func (c *DiskCache) remove(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[name]; ok {
+		c.size -= e.size
+		delete(c.entries, name)
+	}
+}
+
+// evict removes least recently used images until cache fits the limit. Must be called under lock
+func (c *DiskCache) evict() {
+	if c.size <= c.maxBytes {
+		return
+	}
+	names := make([]string, 0, len(c.entries))
+	for name := range c.entries {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return c.entries[names[i]].used.Before(c.entries[names[j]].used)
+	})
+	for _, name := range names {
+		if c.size <= c.maxBytes {
+			return
+		}
+		_ = os.Remove(filepath.Join(c.dir, name))
+		c.size -= c.entries[name].size
+		delete(c.entries, name)
+	}
+}
+
+func fileName(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,61 @@
+package imgproxy
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDiskCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache, err := NewDiskCache(t.TempDir(), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+	tick := func() { now = now.Add(time.Second) }
+
+	if err := cache.Put("a", []byte("aaaa")); err != nil {
+		t.Fatal(err)
+	}
+	tick()
+	if err := cache.Put("b", []byte("bbbb")); err != nil {
+		t.Fatal(err)
+	}
+	tick()
+	if _, ok := cache.Get("a"); !ok {
+		t.Fatal("a should be cached")
+	}
+	tick()
+	if err := cache.Put("c", []byte("cccc")); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := cache.Get("b"); ok {
+		t.Error("b should be evicted as least recently used")
+	}
+	if data, ok := cache.Get("a"); !ok || string(data) != "aaaa" {
+		t.Error("a should stay cached")
+	}
+	if cache.Size() != 8 {
+		t.Errorf("unexpected cache size %d", cache.Size())
+	}
+}
+
+func TestDiskCacheReusesFiles(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := NewDiskCache(dir, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.Put("a", []byte("image")); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewDiskCache(dir, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, ok := reopened.Get("a"); !ok || string(data) != "image" {
+		t.Error("image should survive restart")
+	}
+}
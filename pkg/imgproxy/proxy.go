@@ -0,0 +1,113 @@
+package imgproxy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/singleflight"
+)
+
+const fetchTimeout = 10 * time.Second
+
+// ErrTooLarge is returned when upstream image exceeds maximum object size
+var ErrTooLarge = errors.New("image too large")
+
+// upstreamError is a non-OK response of the upstream
+type upstreamError struct {
+	status int
+}
+
+func (e *upstreamError) Error() string {
+	return fmt.Sprintf("upstream responded with status %d", e.status)
+}
+
+// Proxy fetches images from upstream, keeps them in the disk cache and serves with long cache headers
+type Proxy struct {
+	cache   *DiskCache
+	client  *http.Client
+	maxSize int64
+	maxAge  time.Duration
+	group   singleflight.Group
+}
+
+// NewProxy creates proxy serving images up to maxSize bytes, cached by clients for maxAge
+func NewProxy(cache *DiskCache, maxSize int64, maxAge time.Duration) *Proxy {
+	return &Proxy{
+		cache:   cache,
+		client:  &http.Client{Timeout: fetchTimeout},
+		maxSize: maxSize,
+		maxAge:  maxAge,
+	}
+}
+
+// Serve responds with image of upstream URL, fetching it if missing in the cache
+func (p *Proxy) Serve(w http.ResponseWriter, rq *http.Request, upstream string) {
+	data, ok := p.cache.Get(upstream)
+	if !ok {
+		v, err, _ := p.group.Do(upstream, func() (interface{}, error) {
+			return p.fetch(upstream)
+		})
+		if err != nil {
+			status := http.StatusBadGateway
+			var upErr *upstreamError
+			if errors.As(err, &upErr) && upErr.status == http.StatusNotFound {
+				status = http.StatusNotFound
+			}
+			log.Warnf("Cannot proxy image %s: %v", upstream, err)
+			http.Error(w, http.StatusText(status), status)
+			return
+		}
+		data = v.([]byte)
+	}
+
+	hash := sha256.Sum256(data)
+	etag := `"` + hex.EncodeToString(hash[:12]) + `"`
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "public, max-age="+strconv.Itoa(int(p.maxAge/time.Second)))
+	if match := rq.Header.Get("If-None-Match"); match != "" && strings.Contains(match, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", http.DetectContentType(data))
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	if rq.Method != http.MethodHead {
+		_, _ = w.Write(data)
+	}
+}
+
+func (p *Proxy) fetch(upstream string) ([]byte, error) {
+	rs, err := p.client.Get(upstream)
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Body.Close()
+	if rs.StatusCode != http.StatusOK {
+		return nil, &upstreamError{status: rs.StatusCode}
+	}
+	if ct := rs.Header.Get("Content-Type"); !strings.HasPrefix(ct, "image/") {
+		return nil, fmt.Errorf("unexpected content type %q", ct)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(rs.Body, p.maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > p.maxSize {
+		return nil, ErrTooLarge
+	}
+	if err := p.cache.Put(upstream, data); err != nil {
+		log.Warnf("Cannot cache image %s: %v", upstream, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,68 @@
+package imgproxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var png = []byte("\x89PNG\r\n\x1a\n0000")
+
+func TestProxyServesFromCache(t *testing.T) {
+	calls := 0
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, rq *http.Request) {
+		calls++
+		if rq.URL.Path == "/missing.png" {
+			http.NotFound(w, rq)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write(png)
+	}))
+	defer upstream.Close()
+
+	cache, err := NewDiskCache(t.TempDir(), 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxy := NewProxy(cache, 1<<10, time.Hour)
+
+	for i := 0; i < 2; i++ {
+		rec := httptest.NewRecorder()
+		proxy.Serve(rec, httptest.NewRequest(http.MethodGet, "/images/x", nil), upstream.URL+"/image.png")
+		if rec.Code != http.StatusOK || rec.Body.String() != string(png) {
+			t.Fatalf("unexpected response %d %q", rec.Code, rec.Body.String())
+		}
+		if rec.Header().Get("Content-Type") != "image/png" || rec.Header().Get("Cache-Control") != "public, max-age=3600" {
+			t.Errorf("unexpected headers %v", rec.Header())
+		}
+	}
+	if calls != 1 {
+		t.Errorf("expected single upstream call, got %d", calls)
+	}
+
+	rec := httptest.NewRecorder()
+	proxy.Serve(rec, httptest.NewRequest(http.MethodGet, "/images/y", nil), upstream.URL+"/missing.png")
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", rec.Code)
+	}
+}
+
+func TestProxyRejectsLargeImages(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, rq *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write(png)
+	}))
+	defer upstream.Close()
+
+	cache, err := NewDiskCache(t.TempDir(), 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	NewProxy(cache, 4, time.Hour).Serve(rec, httptest.NewRequest(http.MethodGet, "/images/x", nil), upstream.URL+"/image.png")
+	if rec.Code != http.StatusBadGateway {
+		t.Errorf("expected 502, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,79 @@
+package imgproxy
+
+import (
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+const (
+	youtubeImagesURL    = "https://i.ytimg.com/vi/"
+	contentfulImagesURL = "https://images.ctfassets.net/"
+)
+
+var (
+	youtubeVideoID = regexp.MustCompile(`^[A-Za-z0-9_-]{11}$`)
+
+	// youtubeSizes maps thumbnail sizes as named by the Data API to the image files
+	youtubeSizes = map[string]string{
+		"default":  "default.jpg",
+		"medium":   "mqdefault.jpg",
+		"high":     "hqdefault.jpg",
+		"standard": "sddefault.jpg",
+		"maxres":   "maxresdefault.jpg",
+	}
+
+	// contentfulParams are parameters of Contentful Images API passed to upstream with their allowed values.
+	// Values are limited, so that every asset has a handful of variants in the cache and upstream
+	contentfulParams = map[string][]string{
+		"w":   contentfulSizes,
+		"h":   contentfulSizes,
+		"fm":  {"jpg", "png", "webp", "avif"},
+		"q":   {"50", "75", "90"},
+		"fit": {"pad", "fill", "scale", "crop", "thumb"},
+		"f": {"center", "top", "right", "left", "bottom", "top_right", "top_left", "bottom_right", "bottom_left",
+			"face", "faces"},
+	}
+	contentfulSizes = []string{"80", "160", "320", "480", "640", "960", "1280", "1920"}
+)
+
+// YoutubeURL returns URL of the video thumbnail of the size: default, medium, high, standard or maxres
+func YoutubeURL(videoID, size string) (string, bool) {
+	file, ok := youtubeSizes[size]
+	if !ok || !youtubeVideoID.MatchString(videoID) {
+		return "", false
+	}
+	return youtubeImagesURL + videoID + "/" + file, true
+}
+
+// ContentfulURL returns URL of the asset of the space. Path is {spaceID}/{assetID}/{token}/{fileName}.
+// Images API parameters outside of the allowed values are rejected
+func ContentfulURL(spaceID, path string, query url.Values) (string, bool) {
+	parts := strings.Split(path, "/")
+	if len(parts) != 4 || parts[0] != spaceID {
+		return "", false
+	}
+	for _, part := range parts {
+		if part == "" || part == "." || part == ".." {
+			return "", false
+		}
+	}
+
+	params := url.Values{}
+	for name, allowed := range contentfulParams {
+		v := query.Get(name)
+		if v == "" {
+			continue
+		}
+		if !slices.Contains(allowed, v) {
+			return "", false
+		}
+		params.Set(name, v)
+	}
+	upstream := contentfulImagesURL + path
+	if len(params) > 0 {
+		upstream += "?" + params.Encode()
+	}
+	return upstream, true
+}
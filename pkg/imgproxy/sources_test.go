@@ -0,0 +1,30 @@
+package imgproxy
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestContentfulURL(t *testing.T) {
+	cases := []struct {
+		path, query string
+		expected    string
+	}{
+		{"space/asset/token/logo.png", "", "https://images.ctfassets.net/space/asset/token/logo.png"},
+		{"space/asset/token/logo.png", "w=320&fm=webp&q=75&utm=x", "https://images.ctfassets.net/space/asset/token/logo.png?fm=webp&q=75&w=320"},
+		{"space/asset/token/logo.png", "fit=thumb&f=faces&h=160", "https://images.ctfassets.net/space/asset/token/logo.png?f=faces&fit=thumb&h=160"},
+		{"space/asset/token/logo.png", "w=321", ""},
+		{"space/asset/token/logo.png", "fm=gif", ""},
+		{"space/asset/token/logo.png", "q=1", ""},
+		{"other/asset/token/logo.png", "", ""},
+		{"space/asset/../logo.png", "", ""},
+		{"space/asset/logo.png", "", ""},
+	}
+	for _, c := range cases {
+		query, _ := url.ParseQuery(c.query)
+		upstream, ok := ContentfulURL("space", c.path, query)
+		if ok != (c.expected != "") || upstream != c.expected {
+			t.Errorf("%s?%s: expected %q, got %q", c.path, c.query, c.expected, upstream)
+		}
+	}
+}
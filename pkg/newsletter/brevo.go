@@ -0,0 +1,184 @@
+package newsletter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dghubble/sling"
+)
+
+const (
+	brevoProvider = "brevo"
+	brevoBase     = "https://api.brevo.com/v3/"
+)
+
+// BrevoClient is a Subscriber backed by Brevo (ex-Sendinblue) Contacts API
+type BrevoClient struct {
+	client *sling.Sling
+
+	// DOITemplateID is an ID of double opt-in confirmation template. Required for pending subscriptions
+	DOITemplateID int
+	// DOIRedirectURL is a page user is redirected to after double opt-in confirmation
+	DOIRedirectURL string
+}
+
+type brevoContactRS struct {
+	ID               int64   `json:"id"`
+	Email            string  `json:"email"`
+	EmailBlacklisted bool    `json:"emailBlacklisted"`
+	ListIDs          []int64 `json:"listIds"`
+}
+
+type brevoCreateContactRQ struct {
+	Email         string                 `json:"email"`
+	Attributes    map[string]interface{} `json:"attributes,omitempty"`
+	ListIDs       []int64                `json:"listIds"`
+	UpdateEnabled bool                   `json:"updateEnabled"`
+}
+
+type brevoDOIRQ struct {
+	Email          string                 `json:"email"`
+	Attributes     map[string]interface{} `json:"attributes,omitempty"`
+	IncludeListIDs []int64                `json:"includeListIds"`
+	TemplateID     int                    `json:"templateId"`
+	RedirectionURL string                 `json:"redirectionUrl"`
+}
+
+type brevoErrorRS struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *brevoErrorRS) Error() string {
+	return fmt.Sprintf("brevo: %s: %s", e.Code, e.Message)
+}
+
+// NewBrevoClient creates new Brevo subscriber
+func NewBrevoClient(apiKey string, timeout time.Duration) *BrevoClient {
+	return &BrevoClient{
+		client: sling.New().
+			Base(brevoBase).
+			Client(&http.Client{Timeout: timeout}).
+			Set("api-key", apiKey),
+	}
+}
+
+// Provider returns name of the newsletter provider
+func (c *BrevoClient) Provider() string {
+	return brevoProvider
+}
+
+// Subscribe adds contact to the Brevo list with provided ID.
+// Pending subscriptions are sent through double opt-in confirmation flow
+func (c *BrevoClient) Subscribe(ctx context.Context, listID string, rq *SubscriptionRequest) (*Subscription, error) {
+	id, err := strconv.ParseInt(listID, 10, 64)
+	if err != nil {
+		return nil, NewError(CodeInvalidRequest, "invalid list id, must be numeric")
+	}
+
+	contact, err := c.getContact(ctx, rq.EmailAddress)
+	if err != nil {
+		return nil, err
+	}
+	if contact != nil && !contact.EmailBlacklisted {
+		for _, l := range contact.ListIDs {
+			if l == id {
+				return nil, NewError(CodeAlreadySubscribed, "email address already subscribed")
+			}
+		}
+	}
+
+	attributes := make(map[string]interface{}, len(rq.Fields)+2)
+	for k, v := range rq.Fields {
+		attributes[k] = v
+	}
+	if rq.FirstName != "" {
+		attributes["FIRSTNAME"] = rq.FirstName
+	}
+	if rq.LastName != "" {
+		attributes["LASTNAME"] = rq.LastName
+	}
+
+	subscription := &Subscription{
+		Provider:     brevoProvider,
+		ListID:       listID,
+		EmailAddress: rq.EmailAddress,
+		Status:       rq.Status,
+	}
+
+	if rq.Status == StatusPending {
+		if c.DOITemplateID == 0 {
+			return nil, NewError(CodeProviderUnavailable, "double opt-in template is not configured")
+		}
+		body := &brevoDOIRQ{
+			Email:          rq.EmailAddress,
+			Attributes:     attributes,
+			IncludeListIDs: []int64{id},
+			TemplateID:     c.DOITemplateID,
+			RedirectionURL: c.DOIRedirectURL,
+		}
+		if _, err = c.do(ctx, c.client.New().Post("contacts/doubleOptinConfirmation").BodyJSON(body), nil); err != nil {
+			return nil, err
+		}
+		return subscription, nil
+	}
+
+	body := &brevoCreateContactRQ{
+		Email:         rq.EmailAddress,
+		Attributes:    attributes,
+		ListIDs:       []int64{id},
+		UpdateEnabled: true,
+	}
+	var created brevoContactRS
+	if _, err = c.do(ctx, c.client.New().Post("contacts").BodyJSON(body), &created); err != nil {
+		return nil, err
+	}
+	switch {
+	case created.ID != 0:
+		subscription.ID = strconv.FormatInt(created.ID, 10)
+	case contact != nil:
+		subscription.ID = strconv.FormatInt(contact.ID, 10)
+	}
+	return subscription, nil
+}
+
+// getContact returns contact details or nil if contact is not known by Brevo
+func (c *BrevoClient) getContact(ctx context.Context, email string) (*brevoContactRS, error) {
+	var contact brevoContactRS
+	rs, err := c.do(ctx, c.client.New().Get("contacts/"+url.PathEscape(email)), &contact)
+	if err != nil {
+		if rs != nil && rs.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &contact, nil
+}
+
+func (c *BrevoClient) do(ctx context.Context, s *sling.Sling, success interface{}) (*http.Response, error) {
+	rq, err := s.Request()
+	if err != nil {
+		return nil, AsError(err)
+	}
+	failure := &brevoErrorRS{}
+	rs, err := c.client.Do(rq.WithContext(ctx), success, failure)
+	if rs != nil && rs.StatusCode >= http.StatusBadRequest {
+		if failure.Message == "" {
+			failure.Code = strconv.Itoa(rs.StatusCode)
+			failure.Message = http.StatusText(rs.StatusCode)
+		}
+		if failure.Code == "invalid_parameter" && strings.Contains(strings.ToLower(failure.Message), "email") {
+			return rs, wrapError(CodeInvalidEmail, "invalid email address", failure)
+		}
+		return rs, fromResponse(rs.StatusCode, failure)
+	}
+	if err != nil {
+		return rs, AsError(fmt.Errorf("brevo: %w", err))
+	}
+	return rs, nil
+}
@@ -0,0 +1,127 @@
+package newsletter
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/hanzoai/gochimp3"
+)
+
+// ErrorCode is a stable machine-readable code of a subscription failure
+type ErrorCode string
+
+const (
+	// CodeInvalidRequest means request body is malformed or has invalid values
+	CodeInvalidRequest ErrorCode = "invalid_request"
+	// CodeInvalidEmail means email address is rejected either by validation or by the provider
+	CodeInvalidEmail ErrorCode = "invalid_email"
+	// CodeAlreadySubscribed means address is already subscribed to the list
+	CodeAlreadySubscribed ErrorCode = "already_subscribed"
+	// CodePendingConfirmation means address is subscribed but double opt-in is not confirmed yet
+	CodePendingConfirmation ErrorCode = "pending_confirmation"
+	// CodeComplianceState means provider does not allow re-subscribing the address (unsubscribed, cleaned, forgotten)
+	CodeComplianceState ErrorCode = "compliance_state"
+	// CodeProviderError means provider rejected the request for a reason not covered by other codes
+	CodeProviderError ErrorCode = "provider_error"
+	// CodeProviderUnavailable means provider is not configured, unreachable or failed internally
+	CodeProviderUnavailable ErrorCode = "provider_unavailable"
+)
+
+var statuses = map[ErrorCode]int{
+	CodeInvalidRequest:      http.StatusBadRequest,
+	CodeInvalidEmail:        http.StatusUnprocessableEntity,
+	CodeAlreadySubscribed:   http.StatusConflict,
+	CodePendingConfirmation: http.StatusConflict,
+	CodeComplianceState:     http.StatusUnprocessableEntity,
+	CodeProviderError:       http.StatusBadGateway,
+	CodeProviderUnavailable: http.StatusServiceUnavailable,
+}
+
+// Error is a typed subscription error
+type Error struct {
+	Code    ErrorCode
+	Message string
+	// Suggestion is a corrected email address if provided one looks misspelled
+	Suggestion string
+	Err        error
+}
+
+// NewError creates new subscription error with provided code
+func NewError(code ErrorCode, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+func wrapError(code ErrorCode, message string, err error) *Error {
+	return &Error{Code: code, Message: message, Err: err}
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// HTTPStatus returns HTTP status code corresponding to the error code
+func (e *Error) HTTPStatus() int {
+	if status, ok := statuses[e.Code]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// AsError converts any error to the typed one. Errors of unknown type are treated as provider failures
+func AsError(err error) *Error {
+	var typed *Error
+	if errors.As(err, &typed) {
+		return typed
+	}
+	if isUnavailable(err) {
+		return wrapError(CodeProviderUnavailable, "newsletter provider unavailable", err)
+	}
+	return wrapError(CodeProviderError, "newsletter provider error", err)
+}
+
+// isUnavailable checks whether error is caused by network failure or timeout
+func isUnavailable(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled)
+}
+
+// fromResponse maps failed response of a provider to the typed error
+func fromResponse(status int, err error) *Error {
+	if status >= http.StatusInternalServerError || status == http.StatusTooManyRequests {
+		return wrapError(CodeProviderUnavailable, "newsletter provider unavailable", err)
+	}
+	return wrapError(CodeProviderError, "newsletter provider error", err)
+}
+
+// fromMailchimpError maps Mailchimp API error to the typed one
+func fromMailchimpError(err error) *Error {
+	var apiErr *gochimp3.APIError
+	if !errors.As(err, &apiErr) {
+		return AsError(err)
+	}
+
+	switch {
+	case apiErr.Status >= http.StatusInternalServerError:
+		return wrapError(CodeProviderUnavailable, "newsletter provider unavailable", err)
+	case apiErr.Title == "Member Exists":
+		return wrapError(CodeAlreadySubscribed, "email address already subscribed", err)
+	case apiErr.Title == "Member In Compliance State" || apiErr.Title == "Forgotten Email Not Subscribed":
+		return wrapError(CodeComplianceState, "email address cannot be re-subscribed", err)
+	case apiErr.Title == "Invalid Resource" && strings.Contains(strings.ToLower(apiErr.Detail), "email"):
+		return wrapError(CodeInvalidEmail, "invalid email address", err)
+	case apiErr.Status == http.StatusNotFound:
+		return wrapError(CodeInvalidRequest, "list not found", err)
+	default:
+		return wrapError(CodeProviderError, "newsletter provider error", err)
+	}
+}
@@ -0,0 +1,47 @@
+package newsletter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/hanzoai/gochimp3"
+)
+
+func TestFromMailchimpError(t *testing.T) {
+	cases := []struct {
+		err    error
+		code   ErrorCode
+		status int
+	}{
+		{&gochimp3.APIError{Status: 400, Title: "Member Exists"}, CodeAlreadySubscribed, http.StatusConflict},
+		{&gochimp3.APIError{Status: 400, Title: "Forgotten Email Not Subscribed"}, CodeComplianceState, http.StatusUnprocessableEntity},
+		{&gochimp3.APIError{Status: 400, Title: "Invalid Resource", Detail: "john@example looks fake or invalid, please enter a real email address."}, CodeInvalidEmail, http.StatusUnprocessableEntity},
+		{&gochimp3.APIError{Status: 400, Title: "Invalid Resource", Detail: "Your merge fields were invalid."}, CodeProviderError, http.StatusBadGateway},
+		{&gochimp3.APIError{Status: 503, Title: "Service Unavailable"}, CodeProviderUnavailable, http.StatusServiceUnavailable},
+		{fmt.Errorf("request: %w", context.DeadlineExceeded), CodeProviderUnavailable, http.StatusServiceUnavailable},
+		{errors.New("something else"), CodeProviderError, http.StatusBadGateway},
+	}
+
+	for _, c := range cases {
+		err := fromMailchimpError(c.err)
+		if err.Code != c.code {
+			t.Errorf("%v: expected code %s, got %s", c.err, c.code, err.Code)
+		}
+		if err.HTTPStatus() != c.status {
+			t.Errorf("%v: expected status %d, got %d", c.err, c.status, err.HTTPStatus())
+		}
+		if !errors.Is(err, c.err) {
+			t.Errorf("%v: original error is not wrapped", c.err)
+		}
+	}
+}
+
+func TestAsErrorKeepsTypedErrors(t *testing.T) {
+	typed := NewError(CodePendingConfirmation, "email address already pending")
+	if AsError(fmt.Errorf("wrapped: %w", typed)) != typed {
+		t.Error("typed error is expected to be returned as is")
+	}
+}
@@ -0,0 +1,244 @@
+package newsletter
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/hanzoai/gochimp3"
+	"github.com/reportportal/landing-aggregator/pkg/email"
+)
+
+const mailchimpProvider = "mailchimp"
+
+// MailchimpClient is a Subscriber backed by Mailchimp Marketing API
+type MailchimpClient struct {
+	*gochimp3.API
+
+	// Validator checks email addresses of member requests
+	Validator *email.Validator
+	// MarketingPermissionIDs are GDPR marketing permissions enabled for consented members
+	MarketingPermissionIDs []string
+	// ConsentMergeField is a merge field receiving consent text version
+	ConsentMergeField string
+}
+
+type MailchimpList struct {
+	*gochimp3.ListResponse
+}
+
+type MailchimpMember = gochimp3.Member
+
+type MailchimpMemberRequest = gochimp3.MemberRequest
+
+type MailchimpMemberStatus string
+
+const (
+	MailchimpMemberSubscribed   MailchimpMemberStatus = "subscribed"
+	MailchimpMemberUnsubscribed MailchimpMemberStatus = "unsubscribed"
+	MailchimpMemberPending      MailchimpMemberStatus = "pending"
+	MailchimpMemberCleaned      MailchimpMemberStatus = "cleaned"
+	MailchimpMemberNone         MailchimpMemberStatus = ""
+)
+
+func NewMailchimpClient(apiKey string) *MailchimpClient {
+	client := gochimp3.New(apiKey)
+	return &MailchimpClient{API: client}
+}
+
+// Provider returns name of the newsletter provider
+func (client *MailchimpClient) Provider() string {
+	return mailchimpProvider
+}
+
+// Subscribe adds address to the Mailchimp audience with provided ID
+func (client *MailchimpClient) Subscribe(ctx context.Context, listID string, rq *SubscriptionRequest) (*Subscription, error) {
+	mergeFields := make(map[string]interface{}, len(rq.Fields)+2)
+	for k, v := range rq.Fields {
+		mergeFields[k] = v
+	}
+	if rq.FirstName != "" {
+		mergeFields["FNAME"] = rq.FirstName
+	}
+	if rq.LastName != "" {
+		mergeFields["LNAME"] = rq.LastName
+	}
+
+	memberRequest := &MailchimpMemberRequest{
+		EmailAddress: rq.EmailAddress,
+		Status:       string(rq.Status),
+		MergeFields:  mergeFields,
+		Tags:         rq.Tags,
+	}
+	client.applyConsent(memberRequest, rq.Consent)
+
+	member, err := client.addMember(ctx, listID, memberRequest)
+	if err != nil {
+		return nil, err
+	}
+	return &Subscription{
+		Provider:     mailchimpProvider,
+		ListID:       listID,
+		ID:           member.ID,
+		EmailAddress: member.EmailAddress,
+		Status:       Status(member.Status),
+	}, nil
+}
+
+// AddSubscription parses Mailchimp member request and adds it to the list
+func (client *MailchimpClient) AddSubscription(ctx context.Context, rq io.Reader, listId string, consent *Consent) (*MailchimpMember, error) {
+	memberRequest, err := parseMemberRequestBody(ctx, rq, client.Validator)
+	if err != nil {
+		return nil, err
+	}
+	client.applyConsent(memberRequest, consent)
+	return client.addMember(ctx, listId, memberRequest)
+}
+
+// applyConsent fills signup details, marketing permissions and consent merge field of the member request
+func (client *MailchimpClient) applyConsent(rq *MailchimpMemberRequest, consent *Consent) {
+	if consent == nil {
+		return
+	}
+	rq.IPSignup = consent.IP
+	rq.TimestampSignup = consent.Timestamp.UTC().Format(time.DateTime)
+
+	if len(client.MarketingPermissionIDs) > 0 {
+		permissions := make(gochimp3.MarketingPermissions, len(client.MarketingPermissionIDs))
+		for i, id := range client.MarketingPermissionIDs {
+			permissions[i] = gochimp3.MarketingPermission{MarketingPermissionID: id, Enabled: true}
+		}
+		rq.MarketingPermissions = &permissions
+	}
+
+	if client.ConsentMergeField != "" && consent.TextVersion != "" {
+		if rq.MergeFields == nil {
+			rq.MergeFields = map[string]interface{}{}
+		}
+		rq.MergeFields[client.ConsentMergeField] = consent.TextVersion
+	}
+}
+
+func (client *MailchimpClient) addMember(ctx context.Context, listId string, memberRequest *MailchimpMemberRequest) (*MailchimpMember, error) {
+	list, err := client.getList(ctx, listId)
+	if err != nil {
+		return nil, fromMailchimpError(err)
+	}
+
+	status, err := list.getMemberStatus(memberRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	switch status {
+	case MailchimpMemberSubscribed:
+		return nil, NewError(CodeAlreadySubscribed, "email address already subscribed")
+	case MailchimpMemberPending:
+		return nil, NewError(CodePendingConfirmation, "email address already pending")
+	case MailchimpMemberCleaned:
+		return nil, NewError(CodeComplianceState, "email address cannot be re-subscribed")
+	case MailchimpMemberUnsubscribed:
+	case MailchimpMemberNone:
+		memberRequest.StatusIfNew = memberRequest.Status
+	default:
+		return nil, NewError(CodeProviderError, "unexpected member status "+string(status))
+	}
+
+	response, err := list.AddOrUpdateMember(memberRequest.EmailAddress, memberRequest)
+	if err != nil {
+		if apiErr, ok := err.(*gochimp3.APIError); ok && apiErr.Title == "Member In Compliance State" {
+			response, err = list.ResubsribeMember(memberRequest.EmailAddress, memberRequest)
+			if err != nil {
+				return nil, fromMailchimpError(err)
+			}
+			return response, nil
+		}
+		return nil, fromMailchimpError(err)
+	}
+	return response, nil
+}
+
+func parseMemberRequestBody(ctx context.Context, body io.Reader, validator *email.Validator) (*MailchimpMemberRequest, error) {
+	var requestBody MailchimpMemberRequest
+
+	bytes, err := io.ReadAll(body)
+	if err != nil {
+		return nil, NewError(CodeInvalidRequest, "failed to read request body")
+	}
+
+	err = json.Unmarshal(bytes, &requestBody)
+	if err != nil {
+		return nil, NewError(CodeInvalidRequest, "invalid request body")
+	}
+
+	if requestBody.EmailAddress == "" {
+		return nil, NewError(CodeInvalidEmail, "email address is required")
+	}
+
+	if requestBody.EmailAddress, err = validateEmail(ctx, validator, requestBody.EmailAddress); err != nil {
+		return nil, err
+	}
+
+	if requestBody.Status == "" {
+		requestBody.Status = "subscribed"
+	}
+
+	if requestBody.Status != "subscribed" && requestBody.Status != "pending" {
+		return nil, NewError(CodeInvalidRequest, "invalid status, must be 'subscribed' or 'pending'")
+	}
+
+	return &requestBody, nil
+}
+
+func (l *MailchimpList) getMemberStatus(rq *MailchimpMemberRequest) (MailchimpMemberStatus, error) {
+	member, err := l.GetMember(rq.EmailAddress, &gochimp3.BasicQueryParams{Fields: []string{"status"}})
+	if err != nil {
+		if apiErr, ok := err.(*gochimp3.APIError); ok && apiErr.Status == 404 {
+			return MailchimpMemberNone, nil
+		} else {
+			return "", fromMailchimpError(err)
+		}
+	}
+
+	return MailchimpMemberStatus(member.Status), nil
+}
+
+func (l *MailchimpList) ResubsribeMember(email string, rq *MailchimpMemberRequest) (*MailchimpMember, error) {
+	rq.Status = "pending"
+	return l.UpdateMember(email, rq)
+}
+
+// getList returns the list bound to the context, so that the list requests are canceled together with the caller
+func (c *MailchimpClient) getList(ctx context.Context, id string) (*MailchimpList, error) {
+	api := *c.API
+	api.Transport = &contextTransport{ctx: ctx, next: c.API.Transport}
+	list, err := api.GetList(id, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MailchimpList{list}, nil
+}
+
+// contextTransport attaches context to the requests since gochimp3 API does not accept one
+type contextTransport struct {
+	ctx  context.Context
+	next http.RoundTripper
+}
+
+func (t *contextTransport) RoundTrip(rq *http.Request) (*http.Response, error) {
+	next := t.next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return next.RoundTrip(rq.WithContext(t.ctx))
+}
+
+func CanMakeMailchimpRequest(client *MailchimpClient) error {
+	if client == nil {
+		return NewError(CodeProviderUnavailable, "mailchimp client is not initialized")
+	}
+	return nil
+}
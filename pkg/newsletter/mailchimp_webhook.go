@@ -0,0 +1,211 @@
+package newsletter
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/reportportal/landing-aggregator/pkg/audit"
+	"github.com/reportportal/landing-aggregator/pkg/email"
+	log "github.com/sirupsen/logrus"
+)
+
+const webhookForwardTimeout = 10 * time.Second
+
+// Mailchimp webhook event types
+const (
+	MailchimpEventSubscribe   = "subscribe"
+	MailchimpEventUnsubscribe = "unsubscribe"
+	MailchimpEventProfile     = "profile"
+	MailchimpEventEmail       = "upemail"
+	MailchimpEventCleaned     = "cleaned"
+	MailchimpEventCampaign    = "campaign"
+)
+
+var (
+	// ErrUnknownProfile is returned for webhook profiles which are not configured
+	ErrUnknownProfile = errors.New("unknown webhook profile")
+	// ErrInvalidSecret is returned when webhook secret does not match the profile one
+	ErrInvalidSecret = errors.New("invalid webhook secret")
+	// ErrInvalidEvent is returned for malformed webhook payloads
+	ErrInvalidEvent = errors.New("invalid webhook event")
+)
+
+// MailchimpEvent is a subscriber event sent by Mailchimp webhook
+type MailchimpEvent struct {
+	Profile    string    `json:"profile"`
+	Type       string    `json:"type"`
+	FiredAt    string    `json:"fired_at"`
+	ReceivedAt time.Time `json:"received_at"`
+	ListID     string    `json:"list_id,omitempty"`
+	ID         string    `json:"id,omitempty"`
+	Email      string    `json:"email,omitempty"`
+	OldEmail   string    `json:"old_email,omitempty"`
+	Action     string    `json:"action,omitempty"`
+	Reason     string    `json:"reason,omitempty"`
+	CampaignID string    `json:"campaign_id,omitempty"`
+}
+
+// WebhookStats holds count of received events per profile and event type
+type WebhookStats struct {
+	Events      map[string]map[string]uint64 `json:"events"`
+	Rejected    uint64                       `json:"rejected"`
+	LastEventAt *time.Time                   `json:"last_event_at,omitempty"`
+}
+
+// MailchimpWebhook receives Mailchimp webhook events, records them to the audit log
+// and optionally forwards them to another webhook endpoint
+type MailchimpWebhook struct {
+	// OnEvent is an optional listener called asynchronously for every received event
+	OnEvent func(event *MailchimpEvent)
+
+	secrets map[string]string
+	audit   *audit.Log
+	forward *WebhookClient
+
+	mu    sync.Mutex
+	stats WebhookStats
+}
+
+// NewMailchimpWebhook creates webhook receiver for provided profile secrets.
+// Audit log and forward client are optional
+func NewMailchimpWebhook(secrets map[string]string, auditLog *audit.Log, forward *WebhookClient) *MailchimpWebhook {
+	return &MailchimpWebhook{
+		secrets: secrets,
+		audit:   auditLog,
+		forward: forward,
+		stats:   WebhookStats{Events: map[string]map[string]uint64{}},
+	}
+}
+
+// Authorize checks that profile is configured and provided secret matches it
+func (h *MailchimpWebhook) Authorize(profile, secret string) error {
+	expected, ok := h.secrets[profile]
+	if !ok {
+		h.reject()
+		return ErrUnknownProfile
+	}
+	if expected == "" || subtle.ConstantTimeCompare([]byte(expected), []byte(secret)) != 1 {
+		h.reject()
+		return ErrInvalidSecret
+	}
+	return nil
+}
+
+// Receive parses form-encoded event of the profile, records and forwards it
+func (h *MailchimpWebhook) Receive(profile string, form url.Values) (*MailchimpEvent, error) {
+	event, err := ParseMailchimpEvent(form)
+	if err != nil {
+		h.reject()
+		return nil, err
+	}
+	event.Profile = profile
+	event.ReceivedAt = time.Now()
+
+	h.mu.Lock()
+	if _, ok := h.stats.Events[profile]; !ok {
+		h.stats.Events[profile] = map[string]uint64{}
+	}
+	h.stats.Events[profile][event.Type]++
+	h.stats.LastEventAt = &event.ReceivedAt
+	h.mu.Unlock()
+
+	log.Infof("Mailchimp [%s] %s event for list %s", profile, event.Type, event.ListID)
+	if h.audit != nil {
+		if err = h.audit.Append(event); err != nil {
+			log.Errorf("Cannot record Mailchimp event: %v", err)
+		}
+	}
+
+	if h.forward != nil {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), webhookForwardTimeout)
+			defer cancel()
+			if _, err := h.forward.Post(ctx, event); err != nil {
+				log.Errorf("Cannot forward Mailchimp event: %v", err)
+			}
+		}()
+	}
+	if h.OnEvent != nil {
+		go h.OnEvent(event)
+	}
+	return event, nil
+}
+
+// Stats returns copy of received events counters
+func (h *MailchimpWebhook) Stats() *WebhookStats {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	stats := &WebhookStats{
+		Events:      make(map[string]map[string]uint64, len(h.stats.Events)),
+		Rejected:    h.stats.Rejected,
+		LastEventAt: h.stats.LastEventAt,
+	}
+	for profile, events := range h.stats.Events {
+		stats.Events[profile] = make(map[string]uint64, len(events))
+		for t, count := range events {
+			stats.Events[profile][t] = count
+		}
+	}
+	return stats
+}
+
+// Erase removes events of provided email address from the audit log and returns count of removed events
+func (h *MailchimpWebhook) Erase(address string) (int, error) {
+	address = email.Normalize(address)
+	if h.audit == nil || address == "" {
+		return 0, nil
+	}
+	return h.audit.Rewrite(func(raw json.RawMessage) bool {
+		var event MailchimpEvent
+		if err := json.Unmarshal(raw, &event); err != nil {
+			return true
+		}
+		return !strings.EqualFold(email.Normalize(event.Email), address) && !strings.EqualFold(email.Normalize(event.OldEmail), address)
+	})
+}
+
+func (h *MailchimpWebhook) reject() {
+	h.mu.Lock()
+	h.stats.Rejected++
+	h.mu.Unlock()
+}
+
+// ParseMailchimpEvent maps form-encoded Mailchimp webhook payload to the event
+func ParseMailchimpEvent(form url.Values) (*MailchimpEvent, error) {
+	event := &MailchimpEvent{
+		Type:    form.Get("type"),
+		FiredAt: form.Get("fired_at"),
+		ListID:  form.Get("data[list_id]"),
+		ID:      form.Get("data[id]"),
+		Email:   form.Get("data[email]"),
+		Action:  form.Get("data[action]"),
+		Reason:  form.Get("data[reason]"),
+	}
+
+	switch event.Type {
+	case MailchimpEventSubscribe, MailchimpEventUnsubscribe, MailchimpEventProfile, MailchimpEventCleaned:
+		if event.Email == "" {
+			return nil, ErrInvalidEvent
+		}
+		event.CampaignID = form.Get("data[campaign_id]")
+	case MailchimpEventEmail:
+		event.ID = form.Get("data[new_id]")
+		event.Email = form.Get("data[new_email]")
+		event.OldEmail = form.Get("data[old_email]")
+		if event.Email == "" {
+			return nil, ErrInvalidEvent
+		}
+	case MailchimpEventCampaign:
+		event.CampaignID = event.ID
+	default:
+		return nil, ErrInvalidEvent
+	}
+	return event, nil
+}
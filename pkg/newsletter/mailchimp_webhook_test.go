@@ -0,0 +1,154 @@
+package newsletter
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"github.com/reportportal/landing-aggregator/pkg/audit"
+)
+
+func TestParseMailchimpEvent(t *testing.T) {
+	event, err := ParseMailchimpEvent(url.Values{
+		"type":              {"subscribe"},
+		"fired_at":          {"2026-10-19 10:00:00"},
+		"data[list_id]":     {"list"},
+		"data[id]":          {"abc"},
+		"data[email]":       {"john@example.com"},
+		"data[campaign_id]": {"c1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Type != MailchimpEventSubscribe || event.ListID != "list" || event.ID != "abc" ||
+		event.Email != "john@example.com" || event.CampaignID != "c1" || event.FiredAt != "2026-10-19 10:00:00" {
+		t.Errorf("unexpected event %+v", event)
+	}
+
+	event, err = ParseMailchimpEvent(url.Values{
+		"type":            {"upemail"},
+		"data[new_id]":    {"new"},
+		"data[new_email]": {"new@example.com"},
+		"data[old_email]": {"old@example.com"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.ID != "new" || event.Email != "new@example.com" || event.OldEmail != "old@example.com" {
+		t.Errorf("unexpected email change event %+v", event)
+	}
+
+	invalid := []url.Values{
+		{},
+		{"type": {"unknown"}, "data[email]": {"john@example.com"}},
+		{"type": {"unsubscribe"}},
+		{"type": {"upemail"}, "data[old_email]": {"old@example.com"}},
+	}
+	for _, form := range invalid {
+		if _, err = ParseMailchimpEvent(form); !errors.Is(err, ErrInvalidEvent) {
+			t.Errorf("%v: expected invalid event, got %v", form, err)
+		}
+	}
+}
+
+func TestMailchimpWebhookAuthorize(t *testing.T) {
+	hook := NewMailchimpWebhook(map[string]string{"landing": "s3cr3t", "empty": ""}, nil, nil)
+
+	if err := hook.Authorize("landing", "s3cr3t"); err != nil {
+		t.Errorf("expected valid secret, got %v", err)
+	}
+	if err := hook.Authorize("landing", "wrong"); !errors.Is(err, ErrInvalidSecret) {
+		t.Errorf("expected invalid secret, got %v", err)
+	}
+	if err := hook.Authorize("empty", ""); !errors.Is(err, ErrInvalidSecret) {
+		t.Errorf("expected empty secret to be rejected, got %v", err)
+	}
+	if err := hook.Authorize("docs", "s3cr3t"); !errors.Is(err, ErrUnknownProfile) {
+		t.Errorf("expected unknown profile, got %v", err)
+	}
+	if rejected := hook.Stats().Rejected; rejected != 3 {
+		t.Errorf("expected 3 rejected events, got %d", rejected)
+	}
+}
+
+func TestMailchimpWebhookReceive(t *testing.T) {
+	auditLog, err := audit.Open(filepath.Join(t.TempDir(), "events.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer auditLog.Close()
+	hook := NewMailchimpWebhook(map[string]string{"landing": "s3cr3t"}, auditLog, nil)
+
+	form := url.Values{"type": {"cleaned"}, "data[list_id]": {"list"}, "data[email]": {"john@example.com"}}
+	for i := 0; i < 2; i++ {
+		if _, err = hook.Receive("landing", form); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err = hook.Receive("landing", url.Values{"type": {"unknown"}}); !errors.Is(err, ErrInvalidEvent) {
+		t.Errorf("expected invalid event, got %v", err)
+	}
+
+	stats := hook.Stats()
+	if stats.Events["landing"][MailchimpEventCleaned] != 2 || stats.Rejected != 1 || stats.LastEventAt == nil {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	var recorded []MailchimpEvent
+	err = auditLog.Scan(func(record json.RawMessage) error {
+		var event MailchimpEvent
+		if err := json.Unmarshal(record, &event); err != nil {
+			return err
+		}
+		recorded = append(recorded, event)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recorded) != 2 || recorded[0].Profile != "landing" || recorded[0].Email != "john@example.com" {
+		t.Errorf("unexpected audit records %+v", recorded)
+	}
+}
+
+func TestMailchimpWebhookErase(t *testing.T) {
+	auditLog, err := audit.Open(filepath.Join(t.TempDir(), "events.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer auditLog.Close()
+	hook := NewMailchimpWebhook(map[string]string{"landing": "s3cr3t"}, auditLog, nil)
+
+	events := []url.Values{
+		{"type": {"subscribe"}, "data[email]": {"John@example.com"}},
+		{"type": {"subscribe"}, "data[email]": {"jane@example.com"}},
+		{"type": {"upemail"}, "data[old_email]": {"john@example.com"}, "data[new_email]": {"johnny@example.com"}},
+		{"type": {"campaign"}, "data[id]": {"c1"}},
+	}
+	for _, form := range events {
+		if _, err = hook.Receive("landing", form); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if count, _ := hook.Erase(""); count != 0 {
+		t.Errorf("empty address erased %d events", count)
+	}
+	count, err := hook.Erase("john@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("expected 2 erased events, got %d", count)
+	}
+	left := 0
+	_ = auditLog.Scan(func(json.RawMessage) error {
+		left++
+		return nil
+	})
+	if left != 2 {
+		t.Errorf("expected 2 events left, got %d", left)
+	}
+}
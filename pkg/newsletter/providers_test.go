@@ -0,0 +1,304 @@
+package newsletter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/reportportal/landing-aggregator/pkg/email"
+)
+
+// recorded is a request received by the fake provider
+type recorded struct {
+	method, path string
+	header       http.Header
+	body         map[string]interface{}
+}
+
+// fakeProvider answers requests with the handler and records them
+func fakeProvider(t *testing.T, handler func(rq *recorded) (int, string)) (*httptest.Server, *[]*recorded) {
+	requests := &[]*recorded{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, rq *http.Request) {
+		r := &recorded{method: rq.Method, path: rq.URL.Path, header: rq.Header}
+		if body, _ := io.ReadAll(rq.Body); len(body) > 0 {
+			if err := json.Unmarshal(body, &r.body); err != nil {
+				t.Errorf("request body is not JSON: %s", body)
+			}
+		}
+		*requests = append(*requests, r)
+		status, body := handler(r)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = io.WriteString(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, requests
+}
+
+func expectCode(t *testing.T, err error, code ErrorCode) {
+	t.Helper()
+	var subErr *Error
+	if !errors.As(err, &subErr) || subErr.Code != code {
+		t.Fatalf("expected %s error, got %v", code, err)
+	}
+}
+
+func TestSendGridSubscribe(t *testing.T) {
+	srv, requests := fakeProvider(t, func(rq *recorded) (int, string) {
+		if rq.path == "/marketing/contacts/search/emails" {
+			return http.StatusNotFound, `{"errors":[{"message":"not found"}]}`
+		}
+		return http.StatusAccepted, `{"job_id":"job"}`
+	})
+	client := NewSendGridClient("key", time.Second)
+	client.client.Base(srv.URL + "/")
+
+	sub, err := client.Subscribe(context.Background(), "list", &SubscriptionRequest{EmailAddress: "john@example.com", FirstName: "John", Status: StatusSubscribed})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sub.Provider != sendGridProvider || sub.Status != StatusSubscribed || sub.ListID != "list" {
+		t.Errorf("unexpected subscription %+v", sub)
+	}
+	if len(*requests) != 2 {
+		t.Fatalf("expected search and upsert requests, got %d", len(*requests))
+	}
+	upsert := (*requests)[1]
+	if upsert.method != http.MethodPut || upsert.path != "/marketing/contacts" {
+		t.Errorf("unexpected upsert request %s %s", upsert.method, upsert.path)
+	}
+	if upsert.header.Get("Authorization") != "Bearer key" {
+		t.Errorf("unexpected auth header %q", upsert.header.Get("Authorization"))
+	}
+	contact := upsert.body["contacts"].([]interface{})[0].(map[string]interface{})
+	if contact["email"] != "john@example.com" || contact["first_name"] != "John" || upsert.body["list_ids"].([]interface{})[0] != "list" {
+		t.Errorf("unexpected upsert body %v", upsert.body)
+	}
+}
+
+func TestSendGridErrors(t *testing.T) {
+	cases := []struct {
+		search, upsert int
+		body           string
+		code           ErrorCode
+	}{
+		{http.StatusOK, 0, `{"result":{"john@example.com":{"contact":{"id":"1","list_ids":["list"]}}}}`, CodeAlreadySubscribed},
+		{http.StatusNotFound, http.StatusBadRequest, `{"errors":[{"field":"contacts[0].email","message":"invalid"}]}`, CodeInvalidEmail},
+		{http.StatusNotFound, http.StatusBadRequest, `{"errors":[{"field":"list_ids","message":"invalid"}]}`, CodeProviderError},
+		{http.StatusTooManyRequests, 0, ``, CodeProviderUnavailable},
+		{http.StatusUnauthorized, 0, `{"errors":[{"message":"bad key"}]}`, CodeProviderError},
+	}
+	for _, c := range cases {
+		srv, _ := fakeProvider(t, func(rq *recorded) (int, string) {
+			if rq.path == "/marketing/contacts/search/emails" {
+				if c.upsert != 0 {
+					return c.search, `{}`
+				}
+				return c.search, c.body
+			}
+			return c.upsert, c.body
+		})
+		client := NewSendGridClient("key", time.Second)
+		client.client.Base(srv.URL + "/")
+
+		_, err := client.Subscribe(context.Background(), "list", &SubscriptionRequest{EmailAddress: "john@example.com", Status: StatusSubscribed})
+		expectCode(t, err, c.code)
+	}
+}
+
+func TestSendGridRejectsPending(t *testing.T) {
+	srv, requests := fakeProvider(t, func(*recorded) (int, string) { return http.StatusOK, `{}` })
+	client := NewSendGridClient("key", time.Second)
+	client.client.Base(srv.URL + "/")
+
+	_, err := client.Subscribe(context.Background(), "list", &SubscriptionRequest{EmailAddress: "john@example.com", Status: StatusPending})
+	expectCode(t, err, CodeInvalidRequest)
+	if len(*requests) != 0 {
+		t.Errorf("expected no requests, got %d", len(*requests))
+	}
+}
+
+func TestBrevoSubscribe(t *testing.T) {
+	srv, requests := fakeProvider(t, func(rq *recorded) (int, string) {
+		if rq.method == http.MethodGet {
+			return http.StatusNotFound, `{"code":"document_not_found","message":"Contact does not exist"}`
+		}
+		return http.StatusCreated, `{"id":42}`
+	})
+	client := NewBrevoClient("key", time.Second)
+	client.client.Base(srv.URL + "/")
+
+	sub, err := client.Subscribe(context.Background(), "7", &SubscriptionRequest{EmailAddress: "john@example.com", LastName: "Doe", Status: StatusSubscribed})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sub.ID != "42" || sub.Status != StatusSubscribed {
+		t.Errorf("unexpected subscription %+v", sub)
+	}
+	create := (*requests)[1]
+	if create.path != "/contacts" || create.header.Get("api-key") != "key" {
+		t.Errorf("unexpected create request %s %v", create.path, create.header)
+	}
+	if create.body["email"] != "john@example.com" || create.body["listIds"].([]interface{})[0] != float64(7) ||
+		create.body["attributes"].(map[string]interface{})["LASTNAME"] != "Doe" {
+		t.Errorf("unexpected create body %v", create.body)
+	}
+}
+
+func TestBrevoDoubleOptIn(t *testing.T) {
+	srv, requests := fakeProvider(t, func(rq *recorded) (int, string) {
+		if rq.method == http.MethodGet {
+			return http.StatusNotFound, `{}`
+		}
+		return http.StatusCreated, ``
+	})
+	client := NewBrevoClient("key", time.Second)
+	client.client.Base(srv.URL + "/")
+
+	rq := &SubscriptionRequest{EmailAddress: "john@example.com", Status: StatusPending}
+	_, err := client.Subscribe(context.Background(), "7", rq)
+	expectCode(t, err, CodeProviderUnavailable)
+
+	client.DOITemplateID = 3
+	client.DOIRedirectURL = "https://example.com/confirmed"
+	sub, err := client.Subscribe(context.Background(), "7", rq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sub.Status != StatusPending {
+		t.Errorf("unexpected status %s", sub.Status)
+	}
+	doi := (*requests)[len(*requests)-1]
+	if doi.path != "/contacts/doubleOptinConfirmation" || doi.body["templateId"] != float64(3) ||
+		doi.body["redirectionUrl"] != "https://example.com/confirmed" {
+		t.Errorf("unexpected double opt-in request %s %v", doi.path, doi.body)
+	}
+}
+
+func TestBrevoErrors(t *testing.T) {
+	cases := []struct {
+		get, create int
+		body        string
+		code        ErrorCode
+	}{
+		{http.StatusOK, 0, `{"id":1,"listIds":[7]}`, CodeAlreadySubscribed},
+		{http.StatusNotFound, http.StatusBadRequest, `{"code":"invalid_parameter","message":"email is not valid"}`, CodeInvalidEmail},
+		{http.StatusNotFound, http.StatusBadRequest, `{"code":"invalid_parameter","message":"list is not valid"}`, CodeProviderError},
+		{http.StatusInternalServerError, 0, ``, CodeProviderUnavailable},
+	}
+	for _, c := range cases {
+		srv, _ := fakeProvider(t, func(rq *recorded) (int, string) {
+			if rq.method == http.MethodGet {
+				if c.create != 0 {
+					return c.get, `{}`
+				}
+				return c.get, c.body
+			}
+			return c.create, c.body
+		})
+		client := NewBrevoClient("key", time.Second)
+		client.client.Base(srv.URL + "/")
+
+		_, err := client.Subscribe(context.Background(), "7", &SubscriptionRequest{EmailAddress: "john@example.com", Status: StatusSubscribed})
+		expectCode(t, err, c.code)
+	}
+
+	_, err := NewBrevoClient("key", time.Second).Subscribe(context.Background(), "abc", &SubscriptionRequest{EmailAddress: "john@example.com"})
+	expectCode(t, err, CodeInvalidRequest)
+}
+
+func TestWebhookSubscribe(t *testing.T) {
+	var signature string
+	var payload []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, rq *http.Request) {
+		signature = rq.Header.Get(SignatureHeader)
+		payload, _ = io.ReadAll(rq.Body)
+		_, _ = io.WriteString(w, `{"id":"abc","status":"pending"}`)
+	}))
+	defer srv.Close()
+
+	rq := &SubscriptionRequest{
+		EmailAddress: "john@example.com",
+		Status:       StatusSubscribed,
+		Consent:      &Consent{TextVersion: "v1", IP: "10.0.0.1"},
+	}
+	sub, err := NewWebhookClient(srv.URL, "secret", time.Second).Subscribe(context.Background(), "list", rq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sub.ID != "abc" || sub.Status != StatusPending {
+		t.Errorf("unexpected subscription %+v", sub)
+	}
+	if signature != "sha256="+Sign("secret", payload) {
+		t.Errorf("unexpected signature %q", signature)
+	}
+	var body map[string]interface{}
+	if err = json.Unmarshal(payload, &body); err != nil {
+		t.Fatal(err)
+	}
+	if body["event"] != "subscribe" || body["list_id"] != "list" || body["email_address"] != "john@example.com" {
+		t.Errorf("unexpected payload %v", body)
+	}
+	if c, _ := body["consent"].(map[string]interface{}); c["text_version"] != "v1" || c["ip"] != "10.0.0.1" {
+		t.Errorf("consent is not forwarded %v", body["consent"])
+	}
+}
+
+func TestParseSubscriptionRequestIgnoresConsent(t *testing.T) {
+	body := `{"email_address":"john@example.com","consent":{"ip":"1.2.3.4","captcha_score":1}}`
+	rq, err := ParseSubscriptionRequest(context.Background(), strings.NewReader(body), email.NewValidator())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rq.Consent != nil {
+		t.Errorf("client-provided consent is accepted %+v", rq.Consent)
+	}
+}
+
+func TestWebhookErrors(t *testing.T) {
+	cases := map[int]ErrorCode{
+		http.StatusConflict:            CodeAlreadySubscribed,
+		http.StatusUnprocessableEntity: CodeInvalidEmail,
+		http.StatusBadRequest:          CodeProviderError,
+		http.StatusBadGateway:          CodeProviderUnavailable,
+	}
+	for status, code := range cases {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, rq *http.Request) {
+			if rq.Header.Get(SignatureHeader) != "" {
+				t.Error("payload is signed without secret")
+			}
+			w.WriteHeader(status)
+		}))
+		_, err := NewWebhookClient(srv.URL, "", time.Second).
+			Subscribe(context.Background(), "list", &SubscriptionRequest{EmailAddress: "john@example.com"})
+		srv.Close()
+		expectCode(t, err, code)
+	}
+}
+
+func TestMailchimpPassesContext(t *testing.T) {
+	client := NewMailchimpClient("key-us1")
+	client.Transport = roundTripFunc(func(rq *http.Request) (*http.Response, error) {
+		return nil, rq.Context().Err()
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := client.Subscribe(ctx, "list", &SubscriptionRequest{EmailAddress: "john@example.com", Status: StatusSubscribed})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected canceled request, got %v", err)
+	}
+	expectCode(t, err, CodeProviderUnavailable)
+}
+
+type roundTripFunc func(rq *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(rq *http.Request) (*http.Response, error) {
+	return f(rq)
+}
@@ -0,0 +1,162 @@
+package newsletter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dghubble/sling"
+)
+
+const (
+	sendGridProvider = "sendgrid"
+	sendGridBase     = "https://api.sendgrid.com/v3/"
+)
+
+// SendGridClient is a Subscriber backed by SendGrid Marketing Campaigns API
+type SendGridClient struct {
+	client *sling.Sling
+}
+
+type sendGridContact struct {
+	Email        string                 `json:"email"`
+	FirstName    string                 `json:"first_name,omitempty"`
+	LastName     string                 `json:"last_name,omitempty"`
+	CustomFields map[string]interface{} `json:"custom_fields,omitempty"`
+}
+
+type sendGridUpsertRQ struct {
+	ListIDs  []string          `json:"list_ids"`
+	Contacts []sendGridContact `json:"contacts"`
+}
+
+type sendGridUpsertRS struct {
+	JobID string `json:"job_id"`
+}
+
+type sendGridSearchRQ struct {
+	Emails []string `json:"emails"`
+}
+
+type sendGridSearchRS struct {
+	Result map[string]struct {
+		Contact struct {
+			ID      string   `json:"id"`
+			ListIDs []string `json:"list_ids"`
+		} `json:"contact"`
+	} `json:"result"`
+}
+
+type sendGridError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+type sendGridErrorRS struct {
+	Errors []sendGridError `json:"errors"`
+}
+
+func (e *sendGridErrorRS) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = err.Message
+	}
+	return "sendgrid: " + strings.Join(messages, "; ")
+}
+
+// NewSendGridClient creates new SendGrid subscriber
+func NewSendGridClient(apiKey string, timeout time.Duration) *SendGridClient {
+	return &SendGridClient{
+		client: sling.New().
+			Base(sendGridBase).
+			Client(&http.Client{Timeout: timeout}).
+			Set("Authorization", "Bearer "+apiKey),
+	}
+}
+
+// Provider returns name of the newsletter provider
+func (c *SendGridClient) Provider() string {
+	return sendGridProvider
+}
+
+// Subscribe adds contact to the SendGrid list with provided ID.
+// SendGrid has no double opt-in for marketing contacts, so pending subscriptions are rejected
+func (c *SendGridClient) Subscribe(ctx context.Context, listID string, rq *SubscriptionRequest) (*Subscription, error) {
+	if rq.Status == StatusPending {
+		return nil, NewError(CodeInvalidRequest, "double opt-in is not supported by sendgrid, status must be 'subscribed'")
+	}
+
+	id, lists, err := c.findContact(ctx, rq.EmailAddress)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range lists {
+		if l == listID {
+			return nil, NewError(CodeAlreadySubscribed, "email address already subscribed")
+		}
+	}
+
+	body := &sendGridUpsertRQ{
+		ListIDs: []string{listID},
+		Contacts: []sendGridContact{{
+			Email:        rq.EmailAddress,
+			FirstName:    rq.FirstName,
+			LastName:     rq.LastName,
+			CustomFields: rq.Fields,
+		}},
+	}
+	var upsertRS sendGridUpsertRS
+	if _, err = c.do(ctx, c.client.New().Put("marketing/contacts").BodyJSON(body), &upsertRS); err != nil {
+		return nil, err
+	}
+
+	return &Subscription{
+		Provider:     sendGridProvider,
+		ListID:       listID,
+		ID:           id,
+		EmailAddress: rq.EmailAddress,
+		Status:       StatusSubscribed,
+	}, nil
+}
+
+// findContact looks up contact ID and lists it belongs to. Empty values are returned for unknown contacts
+func (c *SendGridClient) findContact(ctx context.Context, email string) (string, []string, error) {
+	var searchRS sendGridSearchRS
+	rs, err := c.do(ctx, c.client.New().Post("marketing/contacts/search/emails").BodyJSON(&sendGridSearchRQ{Emails: []string{email}}), &searchRS)
+	if err != nil {
+		if rs != nil && rs.StatusCode == http.StatusNotFound {
+			return "", nil, nil
+		}
+		return "", nil, err
+	}
+	for _, r := range searchRS.Result {
+		return r.Contact.ID, r.Contact.ListIDs, nil
+	}
+	return "", nil, nil
+}
+
+func (c *SendGridClient) do(ctx context.Context, s *sling.Sling, success interface{}) (*http.Response, error) {
+	rq, err := s.Request()
+	if err != nil {
+		return nil, AsError(err)
+	}
+	failure := &sendGridErrorRS{}
+	rs, err := c.client.Do(rq.WithContext(ctx), success, failure)
+	if rs != nil && rs.StatusCode >= http.StatusBadRequest {
+		if len(failure.Errors) == 0 {
+			failure.Errors = []sendGridError{{Message: http.StatusText(rs.StatusCode)}}
+		}
+		for _, e := range failure.Errors {
+			if strings.HasSuffix(e.Field, "email") {
+				return rs, wrapError(CodeInvalidEmail, "invalid email address", failure)
+			}
+		}
+		return rs, fromResponse(rs.StatusCode, failure)
+	}
+	if err != nil {
+		return rs, AsError(fmt.Errorf("sendgrid: %w", err))
+	}
+	return rs, nil
+}
@@ -0,0 +1,115 @@
+package newsletter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/reportportal/landing-aggregator/pkg/email"
+)
+
+// Status represents state of a subscription
+type Status string
+
+const (
+	// StatusSubscribed means address is subscribed right away
+	StatusSubscribed Status = "subscribed"
+	// StatusPending means address waits for double opt-in confirmation
+	StatusPending Status = "pending"
+)
+
+// Subscriber is a provider-neutral interface of newsletter service
+type Subscriber interface {
+	// Provider returns name of the newsletter provider
+	Provider() string
+	// Subscribe adds address from the request to the list with provided ID
+	Subscribe(ctx context.Context, listID string, rq *SubscriptionRequest) (*Subscription, error)
+}
+
+// SubscriptionRequest represents provider-neutral subscription request
+type SubscriptionRequest struct {
+	EmailAddress string                 `json:"email_address"`
+	ListID       string                 `json:"list_id,omitempty"`
+	Status       Status                 `json:"status,omitempty"`
+	FirstName    string                 `json:"first_name,omitempty"`
+	LastName     string                 `json:"last_name,omitempty"`
+	Fields       map[string]interface{} `json:"fields,omitempty"`
+	Tags         []string               `json:"tags,omitempty"`
+	// ConsentTextVersion is a version of the consent text shown to the subscriber
+	ConsentTextVersion string `json:"consent_text_version,omitempty"`
+	// Consent is populated by the server and is never read from the request body
+	Consent *Consent `json:"-"`
+}
+
+// Consent holds details of the subscriber consent collected by the server
+type Consent struct {
+	TextVersion       string    `json:"text_version"`
+	IP                string    `json:"ip"`
+	UserAgent         string    `json:"user_agent"`
+	Timestamp         time.Time `json:"timestamp"`
+	CaptchaScore      *float32  `json:"captcha_score,omitempty"`
+	CaptchaAssessment string    `json:"captcha_assessment,omitempty"`
+}
+
+// Subscription represents result of the subscription
+type Subscription struct {
+	Provider     string `json:"provider"`
+	ListID       string `json:"list_id"`
+	ID           string `json:"id,omitempty"`
+	EmailAddress string `json:"email_address"`
+	Status       Status `json:"status"`
+}
+
+// ParseSubscriptionRequest reads and validates subscription request body.
+// Email address is replaced with its normalized form
+func ParseSubscriptionRequest(ctx context.Context, body io.Reader, validator *email.Validator) (*SubscriptionRequest, error) {
+	var rq SubscriptionRequest
+
+	bytes, err := io.ReadAll(body)
+	if err != nil {
+		return nil, NewError(CodeInvalidRequest, "failed to read request body")
+	}
+
+	if err = json.Unmarshal(bytes, &rq); err != nil {
+		return nil, NewError(CodeInvalidRequest, "invalid request body")
+	}
+
+	if rq.EmailAddress == "" {
+		return nil, NewError(CodeInvalidEmail, "email address is required")
+	}
+
+	if rq.EmailAddress, err = validateEmail(ctx, validator, rq.EmailAddress); err != nil {
+		return nil, err
+	}
+
+	if rq.Status == "" {
+		rq.Status = StatusSubscribed
+	}
+
+	if rq.Status != StatusSubscribed && rq.Status != StatusPending {
+		return nil, NewError(CodeInvalidRequest, "invalid status, must be 'subscribed' or 'pending'")
+	}
+
+	return &rq, nil
+}
+
+// validateEmail validates and normalizes the address. If none provided, validator with built-in disposable
+// and typo checks is used, MX lookup is disabled
+func validateEmail(ctx context.Context, validator *email.Validator, address string) (string, error) {
+	if validator == nil {
+		validator = defaultValidator
+	}
+	normalized, err := validator.Validate(ctx, address)
+	if err != nil {
+		var emailErr *email.Error
+		if errors.As(err, &emailErr) {
+			return "", &Error{Code: CodeInvalidEmail, Message: emailErr.Message, Suggestion: emailErr.Suggestion, Err: err}
+		}
+		return "", wrapError(CodeInvalidEmail, "invalid email address", err)
+	}
+	return normalized, nil
+}
+
+var defaultValidator = email.NewValidator()
@@ -0,0 +1,127 @@
+package newsletter
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	webhookProvider = "webhook"
+
+	// SignatureHeader contains HMAC-SHA256 signature of the webhook payload
+	SignatureHeader = "X-Signature"
+)
+
+// WebhookClient is a Subscriber which posts subscription requests to an arbitrary HTTP endpoint
+type WebhookClient struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+// WebhookPayload is a body sent to the webhook endpoint
+type WebhookPayload struct {
+	Event string `json:"event"`
+	*SubscriptionRequest
+	Consent *Consent `json:"consent,omitempty"`
+}
+
+type webhookRS struct {
+	ID     string `json:"id"`
+	Status Status `json:"status"`
+}
+
+// NewWebhookClient creates new webhook subscriber. Payloads are signed with provided secret unless it is empty
+func NewWebhookClient(url, secret string, timeout time.Duration) *WebhookClient {
+	return &WebhookClient{
+		url:    url,
+		secret: secret,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Provider returns name of the newsletter provider
+func (c *WebhookClient) Provider() string {
+	return webhookProvider
+}
+
+// Subscribe posts subscription request to the webhook endpoint.
+// Any 2xx status is treated as success, response body may optionally contain subscription id and status.
+// 409 and 422 statuses are reported as already subscribed and invalid email address accordingly
+func (c *WebhookClient) Subscribe(ctx context.Context, listID string, rq *SubscriptionRequest) (*Subscription, error) {
+	payload := *rq
+	payload.ListID = listID
+	rs, err := c.Post(ctx, &WebhookPayload{Event: "subscribe", SubscriptionRequest: &payload, Consent: rq.Consent})
+	if err != nil {
+		return nil, err
+	}
+
+	subscription := &Subscription{
+		Provider:     webhookProvider,
+		ListID:       listID,
+		EmailAddress: rq.EmailAddress,
+		Status:       rq.Status,
+	}
+	var body webhookRS
+	if len(rs) > 0 && json.Unmarshal(rs, &body) == nil {
+		subscription.ID = body.ID
+		if body.Status != "" {
+			subscription.Status = body.Status
+		}
+	}
+	return subscription, nil
+}
+
+// Post sends signed JSON payload to the webhook endpoint and returns response body
+func (c *WebhookClient) Post(ctx context.Context, payload interface{}) ([]byte, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, AsError(err)
+	}
+
+	rq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, AsError(err)
+	}
+	rq.Header.Set("Content-Type", "application/json")
+	if c.secret != "" {
+		rq.Header.Set(SignatureHeader, "sha256="+Sign(c.secret, body))
+	}
+
+	rs, err := c.client.Do(rq)
+	if err != nil {
+		return nil, AsError(fmt.Errorf("webhook: %w", err))
+	}
+	defer rs.Body.Close()
+
+	rsBody, err := io.ReadAll(io.LimitReader(rs.Body, 1<<16))
+	if err != nil {
+		return nil, AsError(fmt.Errorf("webhook: %w", err))
+	}
+
+	switch {
+	case rs.StatusCode == http.StatusConflict:
+		return nil, NewError(CodeAlreadySubscribed, "email address already subscribed")
+	case rs.StatusCode == http.StatusUnprocessableEntity:
+		return nil, NewError(CodeInvalidEmail, "invalid email address")
+	case rs.StatusCode < 200 || rs.StatusCode > 299:
+		return nil, fromResponse(rs.StatusCode, errors.New("webhook: unexpected response status "+rs.Status))
+	}
+	return rsBody, nil
+}
+
+// Sign calculates hex-encoded HMAC-SHA256 signature of the payload
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
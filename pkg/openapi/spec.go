@@ -0,0 +1,291 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-chi/chi/v5"
+	log "github.com/sirupsen/logrus"
+)
+
+const version = "3.0.3"
+
+var pathParam = regexp.MustCompile(`\{([^}:]+)(:[^}]*)?\}`)
+
+// Document is an OpenAPI 3 document
+type Document struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       Info                             `json:"info"`
+	Paths      map[string]map[string]*Operation `json:"paths"`
+	Components Components                       `json:"components"`
+}
+
+// Info describes the API
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// Components holds schemas referenced by operations
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// Operation describes a single route
+type Operation struct {
+	ID          string               `json:"operationId"`
+	Summary     string               `json:"summary,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+
+	spec *Spec
+}
+
+// Parameter describes path or query parameter
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+// RequestBody describes JSON body of the request
+type RequestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// Response describes response of the operation
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType holds schema of the content
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Spec collects descriptions of operations and builds OpenAPI document of the routes they belong to
+type Spec struct {
+	title      string
+	version    string
+	schemas    *schemas
+	operations map[string]*Operation
+	hidden     []string
+}
+
+// New creates specification of the API
+func New(title, version string) *Spec {
+	if version == "" {
+		version = "dev"
+	}
+	return &Spec{title: title, version: version, schemas: newSchemas(), operations: map[string]*Operation{}}
+}
+
+// Operation returns description of the route, the pattern is the same as registered in the router
+func (s *Spec) Operation(method, pattern string) *Operation {
+	key := operationKey(method, pattern)
+	if op, ok := s.operations[key]; ok {
+		return op
+	}
+	op := &Operation{ID: operationID(method, pattern), Responses: map[string]*Response{}, spec: s}
+	s.operations[key] = op
+	return op
+}
+
+// Describe sets short description of the operation
+func (op *Operation) Describe(summary string) *Operation {
+	op.Summary = summary
+	return op
+}
+
+// Tag groups operation with others
+func (op *Operation) Tag(tags ...string) *Operation {
+	op.Tags = append(op.Tags, tags...)
+	return op
+}
+
+// Query adds query parameter
+func (op *Operation) Query(name string, schema *Schema, required bool) *Operation {
+	op.Parameters = append(op.Parameters, &Parameter{Name: name, In: "query", Required: required, Schema: schema})
+	return op
+}
+
+// Body sets JSON request body of the type of provided value with the required top-level fields
+func (op *Operation) Body(v interface{}, required ...string) *Operation {
+	schema := *op.spec.schemas.resolve(op.spec.schemas.of(v))
+	schema.Required = required
+	op.RequestBody = &RequestBody{
+		Required: true,
+		Content:  map[string]*MediaType{"application/json": {Schema: &schema}},
+	}
+	return op
+}
+
+// Response adds JSON response of the type of provided value. Nil value means response without body
+func (op *Operation) Response(status int, description string, v interface{}) *Operation {
+	rs := &Response{Description: description}
+	if v != nil {
+		rs.Content = map[string]*MediaType{"application/json": {Schema: op.spec.schemas.of(v)}}
+	}
+	op.Responses[strconv.Itoa(status)] = rs
+	return op
+}
+
+// Hide excludes routes under path prefixes from the document, e.g. internal or admin routes.
+// Prefixes are matched on path segments
+func (s *Spec) Hide(prefixes ...string) {
+	s.hidden = append(s.hidden, prefixes...)
+}
+
+// Document builds document of all routes of the router except hidden ones. Routes without description are included
+// with generic response, descriptions of missing routes are reported as stale
+func (s *Spec) Document(routes chi.Routes) *Document {
+	doc := &Document{
+		OpenAPI:    version,
+		Info:       Info{Title: s.title, Version: s.version},
+		Paths:      map[string]map[string]*Operation{},
+		Components: Components{Schemas: s.schemas.components},
+	}
+
+	found := map[string]bool{}
+	_ = chi.Walk(routes, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		pattern := normalize(route)
+		key := operationKey(method, pattern)
+		found[key] = true
+		if s.isHidden(pattern) {
+			return nil
+		}
+
+		op, ok := s.operations[key]
+		if !ok {
+			op = &Operation{ID: operationID(method, pattern), Responses: map[string]*Response{}}
+		}
+		described := *op
+		described.Parameters = append(pathParameters(pattern), op.Parameters...)
+		if len(described.Responses) == 0 {
+			described.Responses = map[string]*Response{"200": {Description: "OK"}}
+		}
+
+		path := pathParam.ReplaceAllString(strings.ReplaceAll(pattern, "*", "{path}"), "{$1}")
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = map[string]*Operation{}
+		}
+		doc.Paths[path][strings.ToLower(method)] = &described
+		return nil
+	})
+
+	stale := []string{}
+	for key := range s.operations {
+		if !found[key] {
+			stale = append(stale, key)
+		}
+	}
+	sort.Strings(stale)
+	for _, key := range stale {
+		log.Warnf("OpenAPI operation %s has no route", key)
+	}
+	return doc
+}
+
+// isHidden reports whether the route pattern is under one of the hidden prefixes
+func (s *Spec) isHidden(pattern string) bool {
+	for _, prefix := range s.hidden {
+		prefix = strings.TrimSuffix(prefix, "/")
+		if pattern == prefix || strings.HasPrefix(pattern, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// operation returns description of the route matching the request
+func (s *Spec) operation(routes chi.Routes, rq *http.Request) *Operation {
+	pattern := routes.Find(chi.NewRouteContext(), rq.Method, rq.URL.Path)
+	if pattern == "" {
+		return nil
+	}
+	return s.operations[operationKey(rq.Method, normalize(pattern))]
+}
+
+func operationKey(method, pattern string) string {
+	return strings.ToUpper(method) + " " + normalize(pattern)
+}
+
+// normalize removes trailing slash of sub-router routes, e.g. /subscriptions/
+func normalize(pattern string) string {
+	if len(pattern) > 1 {
+		pattern = strings.TrimSuffix(pattern, "/")
+	}
+	return strings.ReplaceAll(pattern, "//", "/")
+}
+
+// operationID builds ID from method and path, e.g. GET /youtube/{feed} is getYoutubeByFeed
+func operationID(method, pattern string) string {
+	id := strings.ToLower(method)
+	for _, segment := range strings.Split(pattern, "/") {
+		switch {
+		case segment == "":
+		case segment == "*":
+			id += "ByPath"
+		case strings.HasPrefix(segment, "{"):
+			id += "By" + camel(pathParam.ReplaceAllString(segment, "$1"))
+		default:
+			id += camel(segment)
+		}
+	}
+	return id
+}
+
+// camel converts path segment like contribution-stats to ContributionStats
+func camel(s string) string {
+	parts := strings.FieldsFunc(s, func(r rune) bool {
+		return r == '-' || r == '_' || r == '.'
+	})
+	for i, p := range parts {
+		parts[i] = strings.ToUpper(p[:1]) + p[1:]
+	}
+	return strings.Join(parts, "")
+}
+
+func pathParameters(pattern string) []*Parameter {
+	params := []*Parameter{}
+	for _, m := range pathParam.FindAllStringSubmatch(pattern, -1) {
+		params = append(params, &Parameter{Name: m[1], In: "path", Required: true, Schema: &Schema{Type: "string"}})
+	}
+	if strings.HasSuffix(pattern, "*") {
+		params = append(params, &Parameter{Name: "path", In: "path", Required: true, Schema: &Schema{Type: "string"}})
+	}
+	return params
+}
+
+// Handler serves OpenAPI document of the routes. The document is built on the first request
+// so that routes registered after the handler are included as well
+func (s *Spec) Handler(routes chi.Routes) http.HandlerFunc {
+	var (
+		once sync.Once
+		body []byte
+	)
+	return func(w http.ResponseWriter, rq *http.Request) {
+		once.Do(func() {
+			var err error
+			if body, err = json.Marshal(s.Document(routes)); err != nil {
+				log.Errorf("Unable to build OpenAPI document: %v", err)
+			}
+		})
+		if body == nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		_, _ = w.Write(body)
+	}
+}
@@ -0,0 +1,129 @@
+package openapi
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type item struct {
+	ID      string    `json:"id"`
+	Created time.Time `json:"created"`
+	Tags    []string  `json:"tags,omitempty"`
+	Parent  *item     `json:"parent,omitempty"`
+	hidden  string
+}
+
+type itemRequest struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+	Items []item `json:"items,omitempty"`
+}
+
+func testRouter(spec *Spec) chi.Router {
+	router := chi.NewRouter()
+	router.Use(spec.Validator(router, func(w http.ResponseWriter, rq *http.Request, err *ValidationError) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	}))
+	ok := func(w http.ResponseWriter, rq *http.Request) {
+		body, _ := io.ReadAll(rq.Body)
+		_, _ = w.Write(body)
+	}
+	router.Get("/items", ok)
+	router.Get("/items/{id}", ok)
+	router.Route("/orders", func(r chi.Router) {
+		r.Post("/", ok)
+	})
+	router.Get("/files/*", ok)
+	router.Get("/admin/items", ok)
+	return router
+}
+
+func testSpec() *Spec {
+	spec := New("test", "1.0")
+	spec.Operation(http.MethodGet, "/items").
+		Query("count", Integer(1, 10), false).
+		Query("sort", String("asc", "desc"), false).
+		Response(http.StatusOK, "Items", []item{})
+	spec.Operation(http.MethodPost, "/orders").Body(itemRequest{}, "name")
+	spec.Operation(http.MethodGet, "/removed")
+	spec.Hide("/admin")
+	return spec
+}
+
+func TestDocument(t *testing.T) {
+	spec := testSpec()
+	doc := spec.Document(testRouter(spec))
+
+	for _, path := range []string{"/items", "/items/{id}", "/orders", "/files/{path}"} {
+		if doc.Paths[path] == nil {
+			t.Errorf("path %s is missing", path)
+		}
+	}
+	if _, ok := doc.Paths["/removed"]; ok {
+		t.Error("operation without route is documented")
+	}
+	if _, ok := doc.Paths["/admin/items"]; ok {
+		t.Error("hidden route is documented")
+	}
+
+	get := doc.Paths["/items/{id}"]["get"]
+	if get.ID != "getItemsById" || len(get.Parameters) != 1 || get.Parameters[0].In != "path" {
+		t.Errorf("unexpected operation %+v", get)
+	}
+	if get.Responses["200"] == nil {
+		t.Error("default response is missing")
+	}
+
+	schema := doc.Components.Schemas["item"]
+	if schema == nil {
+		t.Fatal("item schema is not registered")
+	}
+	if schema.Properties["created"].Format != "date-time" || schema.Properties["parent"].Ref != "#/components/schemas/item" {
+		t.Errorf("unexpected item schema %+v", schema.Properties)
+	}
+	if _, ok := schema.Properties["hidden"]; ok {
+		t.Error("unexported field is documented")
+	}
+}
+
+func TestValidator(t *testing.T) {
+	router := testRouter(testSpec())
+	tests := []struct {
+		method, target, body string
+		code                 int
+	}{
+		{http.MethodGet, "/items", "", http.StatusOK},
+		{http.MethodGet, "/items?count=10&sort=asc", "", http.StatusOK},
+		{http.MethodGet, "/items?count=0", "", http.StatusBadRequest},
+		{http.MethodGet, "/items?count=11", "", http.StatusBadRequest},
+		{http.MethodGet, "/items?count=x", "", http.StatusBadRequest},
+		{http.MethodGet, "/items?sort=up", "", http.StatusBadRequest},
+		{http.MethodGet, "/items/1?count=x", "", http.StatusOK},
+		{http.MethodPost, "/orders", `{"name":"a","count":1,"items":[{"id":"1"}]}`, http.StatusOK},
+		{http.MethodPost, "/orders", `{"name":"a","unknown":true}`, http.StatusOK},
+		{http.MethodPost, "/orders", `{"count":1}`, http.StatusBadRequest},
+		{http.MethodPost, "/orders", `{"name":"a","count":1.5}`, http.StatusBadRequest},
+		{http.MethodPost, "/orders", `{"name":"a","items":[{"id":1}]}`, http.StatusBadRequest},
+		{http.MethodPost, "/orders", `[]`, http.StatusBadRequest},
+		{http.MethodPost, "/orders", `{`, http.StatusBadRequest},
+		{http.MethodPost, "/orders", `{"name":"` + strings.Repeat("a", maxBodySize) + `"}`, http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(test.method, test.target, strings.NewReader(test.body)))
+		if rec.Code != test.code {
+			t.Errorf("%s %s %s: expected %d, got %d %s", test.method, test.target, test.body, test.code, rec.Code, rec.Body.String())
+			continue
+		}
+		// handler reads the same body the validator consumed
+		if rec.Code == http.StatusOK && rec.Body.String() != test.body {
+			t.Errorf("%s %s: body is not restored, got %q", test.method, test.target, rec.Body.String())
+		}
+	}
+}
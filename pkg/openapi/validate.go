@@ -0,0 +1,222 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// maxBodySize limits bodies read for validation. Validation runs before rate limits of the handlers,
+// so larger bodies are rejected without being buffered
+const maxBodySize = 64 << 10
+
+// ValidationError describes request not matching the specification
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Field == "" {
+		return e.Message
+	}
+	return e.Field + ": " + e.Message
+}
+
+// Validator returns middleware validating query parameters and JSON bodies of described operations.
+// Invalid requests are passed to onError instead of the handler
+func (s *Spec) Validator(routes chi.Routes, onError func(w http.ResponseWriter, rq *http.Request, err *ValidationError)) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, rq *http.Request) {
+			op := s.operation(routes, rq)
+			if op == nil {
+				next.ServeHTTP(w, rq)
+				return
+			}
+			if err := op.validateQuery(rq); err != nil {
+				onError(w, rq, err)
+				return
+			}
+			if op.RequestBody != nil {
+				body, err := io.ReadAll(io.LimitReader(rq.Body, maxBodySize+1))
+				if err != nil {
+					onError(w, rq, &ValidationError{Message: "cannot read request body"})
+					return
+				}
+				if len(body) > maxBodySize {
+					onError(w, rq, &ValidationError{Message: "request body is too large"})
+					return
+				}
+				if verr := op.validateBody(body); verr != nil {
+					onError(w, rq, verr)
+					return
+				}
+				rq.Body = io.NopCloser(bytes.NewReader(body))
+			}
+			next.ServeHTTP(w, rq)
+		})
+	}
+}
+
+func (op *Operation) validateQuery(rq *http.Request) *ValidationError {
+	query := rq.URL.Query()
+	for _, p := range op.Parameters {
+		if p.In != "query" {
+			continue
+		}
+		values, ok := query[p.Name]
+		if !ok {
+			if p.Required {
+				return &ValidationError{Field: p.Name, Message: "is required"}
+			}
+			continue
+		}
+		for _, v := range values {
+			if err := validateValue(p.Name, p.Schema, v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (op *Operation) validateBody(body []byte) *ValidationError {
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return &ValidationError{Message: "request body is not valid JSON"}
+	}
+	return op.spec.schemas.validate("", op.RequestBody.Content["application/json"].Schema, v)
+}
+
+// validateValue validates string value of the query parameter
+func validateValue(name string, schema *Schema, value string) *ValidationError {
+	switch schema.Type {
+	case "integer":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return &ValidationError{Field: name, Message: "must be an integer"}
+		}
+		return validateNumber(name, schema, float64(n))
+	case "number":
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return &ValidationError{Field: name, Message: "must be a number"}
+		}
+		return validateNumber(name, schema, n)
+	case "boolean":
+		if _, err := strconv.ParseBool(value); err != nil {
+			return &ValidationError{Field: name, Message: "must be a boolean"}
+		}
+	}
+	return validateEnum(name, schema, value)
+}
+
+// validate validates decoded JSON value
+func (s *schemas) validate(name string, schema *Schema, v interface{}) *ValidationError {
+	schema = s.resolve(schema)
+	if v == nil {
+		// encoding/json leaves fields untouched on null, so only the body itself must be present
+		if name != "" || schema.Type == "" || schema.Nullable {
+			return nil
+		}
+		return &ValidationError{Message: "request body must not be null"}
+	}
+
+	switch schema.Type {
+	case "object":
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return &ValidationError{Field: name, Message: "must be an object"}
+		}
+		for _, field := range schema.Required {
+			if _, ok := obj[field]; !ok {
+				return &ValidationError{Field: join(name, field), Message: "is required"}
+			}
+		}
+		keys := make([]string, 0, len(obj))
+		for key := range obj {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			prop, ok := schema.Properties[key]
+			if !ok {
+				prop = schema.AdditionalProperties
+			}
+			if prop == nil {
+				continue
+			}
+			if err := s.validate(join(name, key), prop, obj[key]); err != nil {
+				return err
+			}
+		}
+	case "array":
+		items, ok := v.([]interface{})
+		if !ok {
+			return &ValidationError{Field: name, Message: "must be an array"}
+		}
+		for i, item := range items {
+			if err := s.validate(fmt.Sprintf("%s[%d]", name, i), schema.Items, item); err != nil {
+				return err
+			}
+		}
+	case "string":
+		str, ok := v.(string)
+		if !ok {
+			return &ValidationError{Field: name, Message: "must be a string"}
+		}
+		if schema.MinLength != nil && len(str) < *schema.MinLength {
+			return &ValidationError{Field: name, Message: fmt.Sprintf("must be at least %d characters long", *schema.MinLength)}
+		}
+		return validateEnum(name, schema, str)
+	case "integer", "number":
+		n, ok := v.(float64)
+		if !ok {
+			return &ValidationError{Field: name, Message: "must be a " + schema.Type}
+		}
+		if schema.Type == "integer" && n != float64(int64(n)) {
+			return &ValidationError{Field: name, Message: "must be an integer"}
+		}
+		return validateNumber(name, schema, n)
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return &ValidationError{Field: name, Message: "must be a boolean"}
+		}
+	}
+	return nil
+}
+
+func validateNumber(name string, schema *Schema, n float64) *ValidationError {
+	if schema.Minimum != nil && n < *schema.Minimum {
+		return &ValidationError{Field: name, Message: fmt.Sprintf("must be at least %v", *schema.Minimum)}
+	}
+	if schema.Maximum != nil && n > *schema.Maximum {
+		return &ValidationError{Field: name, Message: fmt.Sprintf("must be at most %v", *schema.Maximum)}
+	}
+	return nil
+}
+
+func validateEnum(name string, schema *Schema, value string) *ValidationError {
+	if len(schema.Enum) == 0 {
+		return nil
+	}
+	for _, allowed := range schema.Enum {
+		if value == allowed {
+			return nil
+		}
+	}
+	return &ValidationError{Field: name, Message: fmt.Sprintf("must be one of %v", schema.Enum)}
+}
+
+func join(prefix, field string) string {
+	if prefix == "" {
+		return field
+	}
+	return prefix + "." + field
+}
@@ -0,0 +1,85 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Limit is a token bucket refilled with Rate tokens per second up to Burst tokens
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// PerMinute creates limit allowing n requests per minute with provided burst
+func PerMinute(n float64, burst int) Limit {
+	return Limit{Rate: n / 60, Burst: burst}
+}
+
+// Enabled reports whether limit restricts anything
+func (l Limit) Enabled() bool {
+	return l.Rate > 0 && l.Burst > 0
+}
+
+// Store keeps token buckets. Memory store serves single instance,
+// shared implementations let several instances enforce common limits
+type Store interface {
+	// Take consumes a token of the bucket. If bucket is empty it returns false and time until the next token
+	Take(ctx context.Context, key string, limit Limit) (bool, time.Duration, error)
+}
+
+// Error is returned when request exceeds the limit
+type Error struct {
+	Scope      string
+	RetryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("too many requests per %s, retry in %s", e.Scope, e.RetryAfter)
+}
+
+// RetryAfterSeconds returns value of the Retry-After header
+func (e *Error) RetryAfterSeconds() int {
+	return int(math.Ceil(e.RetryAfter.Seconds()))
+}
+
+// Limiter applies limits of named scopes (e.g. ip, email, list) to the keys within those scopes
+type Limiter struct {
+	store  Store
+	limits map[string]Limit
+}
+
+// NewLimiter creates limiter on top of provided store
+func NewLimiter(store Store) *Limiter {
+	return &Limiter{store: store, limits: map[string]Limit{}}
+}
+
+// SetLimit sets limit of the scope. Disabled limits are ignored
+func (l *Limiter) SetLimit(scope string, limit Limit) {
+	if !limit.Enabled() {
+		delete(l.limits, scope)
+		return
+	}
+	l.limits[scope] = limit
+}
+
+// Allow consumes a token of the key within the scope. Returns *Error if limit is exceeded
+// and store error if limit cannot be checked. Scopes without limit and empty keys are always allowed
+func (l *Limiter) Allow(ctx context.Context, scope, key string) error {
+	limit, ok := l.limits[scope]
+	if !ok || key == "" {
+		return nil
+	}
+
+	allowed, retryAfter, err := l.store.Take(ctx, scope+":"+strings.ToLower(key), limit)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return &Error{Scope: scope, RetryAfter: retryAfter}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLimiter(t *testing.T) {
+	now := time.Now()
+	store := NewMemoryStore(time.Hour)
+	defer store.Close()
+	store.now = func() time.Time { return now }
+
+	l := NewLimiter(store)
+	l.SetLimit("ip", PerMinute(6, 2))
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if err := l.Allow(ctx, "ip", "10.0.0.1"); err != nil {
+			t.Fatalf("request %d within burst rejected: %v", i, err)
+		}
+	}
+
+	var limitErr *Error
+	if err := l.Allow(ctx, "ip", "10.0.0.1"); !errors.As(err, &limitErr) {
+		t.Fatalf("request over burst must be rejected, got %v", err)
+	}
+	if limitErr.RetryAfterSeconds() != 10 {
+		t.Errorf("expected retry after 10s, got %ds", limitErr.RetryAfterSeconds())
+	}
+	if err := l.Allow(ctx, "ip", "10.0.0.2"); err != nil {
+		t.Errorf("other key must have own bucket: %v", err)
+	}
+	if err := l.Allow(ctx, "email", "john@example.com"); err != nil {
+		t.Errorf("scope without limit must be allowed: %v", err)
+	}
+
+	now = now.Add(10 * time.Second)
+	if err := l.Allow(ctx, "ip", "10.0.0.1"); err != nil {
+		t.Errorf("refilled token rejected: %v", err)
+	}
+
+	now = now.Add(time.Minute)
+	store.evict()
+	if len(store.buckets) != 0 {
+		t.Errorf("full buckets must be evicted, %d left", len(store.buckets))
+	}
+}
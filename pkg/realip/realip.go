@@ -0,0 +1,89 @@
+package realip
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Resolver finds the real client address of requests passed through trusted reverse proxies
+type Resolver struct {
+	trusted []*net.IPNet
+}
+
+// NewResolver creates resolver trusting provided proxy addresses or CIDR ranges
+func NewResolver(proxies []string) (*Resolver, error) {
+	r := &Resolver{}
+	for _, proxy := range proxies {
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "" {
+			continue
+		}
+		if !strings.Contains(proxy, "/") {
+			if ip := net.ParseIP(proxy); ip != nil && ip.To4() != nil {
+				proxy += "/32"
+			} else {
+				proxy += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", proxy, err)
+		}
+		r.trusted = append(r.trusted, network)
+	}
+	return r, nil
+}
+
+// ClientIP returns address of the client. Forwarding headers are honoured only if the request comes
+// from a trusted proxy: X-Forwarded-For is walked from the right skipping trusted hops, X-Real-IP is used otherwise
+func (r *Resolver) ClientIP(rq *http.Request) string {
+	remote := rq.RemoteAddr
+	if host, _, err := net.SplitHostPort(remote); err == nil {
+		remote = host
+	}
+	if !r.isTrusted(remote) {
+		return remote
+	}
+
+	if xff := rq.Header.Values("X-Forwarded-For"); len(xff) > 0 {
+		hops := strings.Split(strings.Join(xff, ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if net.ParseIP(hop) == nil {
+				break
+			}
+			if !r.isTrusted(hop) {
+				return hop
+			}
+		}
+	}
+	if realIP := strings.TrimSpace(rq.Header.Get("X-Real-IP")); net.ParseIP(realIP) != nil {
+		return realIP
+	}
+	return remote
+}
+
+// Middleware replaces remote address of the request with the resolved client address
+func (r *Resolver) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, rq *http.Request) {
+		if len(r.trusted) > 0 {
+			rq.RemoteAddr = r.ClientIP(rq)
+		}
+		next.ServeHTTP(w, rq)
+	})
+}
+
+func (r *Resolver) isTrusted(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, network := range r.trusted {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
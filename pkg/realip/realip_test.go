@@ -0,0 +1,76 @@
+package realip
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	r, err := NewResolver([]string{"10.0.0.0/8", "192.168.1.1", "fd00::1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name     string
+		remote   string
+		xff      []string
+		realIP   string
+		expected string
+	}{
+		{"direct client", "203.0.113.7:1234", nil, "", "203.0.113.7"},
+		{"untrusted peer spoofs forwarding headers", "203.0.113.7:1234", []string{"1.2.3.4"}, "5.6.7.8", "203.0.113.7"},
+		{"trusted proxy", "10.0.0.1:80", []string{"198.51.100.1"}, "", "198.51.100.1"},
+		{"client prepends fake hop", "10.0.0.1:80", []string{"1.2.3.4, 198.51.100.1"}, "", "198.51.100.1"},
+		{"chain of trusted proxies", "10.0.0.1:80", []string{"198.51.100.1, 10.0.0.2", "192.168.1.1"}, "", "198.51.100.1"},
+		{"client spoofs trusted address", "10.0.0.1:80", []string{"10.0.0.5, 198.51.100.1"}, "", "198.51.100.1"},
+		{"garbage hop stops the walk", "10.0.0.1:80", []string{"198.51.100.1, unknown"}, "", "10.0.0.1"},
+		{"only trusted hops", "10.0.0.1:80", []string{"10.0.0.2"}, "", "10.0.0.1"},
+		{"real ip header of trusted proxy", "10.0.0.1:80", nil, "198.51.100.1", "198.51.100.1"},
+		{"invalid real ip header", "10.0.0.1:80", nil, "not an ip", "10.0.0.1"},
+		{"ipv6 trusted proxy", "[fd00::1]:80", []string{"2001:db8::1"}, "", "2001:db8::1"},
+		{"ipv6 untrusted peer", "[fd00::2]:80", []string{"2001:db8::1"}, "", "fd00::2"},
+	}
+	for _, c := range cases {
+		rq := httptest.NewRequest(http.MethodGet, "/", nil)
+		rq.RemoteAddr = c.remote
+		for _, xff := range c.xff {
+			rq.Header.Add("X-Forwarded-For", xff)
+		}
+		if c.realIP != "" {
+			rq.Header.Set("X-Real-IP", c.realIP)
+		}
+		if ip := r.ClientIP(rq); ip != c.expected {
+			t.Errorf("%s: expected %s, got %s", c.name, c.expected, ip)
+		}
+	}
+}
+
+func TestMiddlewareWithoutTrustedProxies(t *testing.T) {
+	r, err := NewResolver(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var remote string
+	handler := r.Middleware(http.HandlerFunc(func(w http.ResponseWriter, rq *http.Request) {
+		remote = rq.RemoteAddr
+	}))
+
+	rq := httptest.NewRequest(http.MethodGet, "/", nil)
+	rq.RemoteAddr = "203.0.113.7:1234"
+	rq.Header.Set("X-Forwarded-For", "1.2.3.4")
+	handler.ServeHTTP(httptest.NewRecorder(), rq)
+	if remote != "203.0.113.7:1234" {
+		t.Errorf("forwarding headers must be ignored, got %s", remote)
+	}
+}
+
+func TestNewResolverRejectsInvalidProxy(t *testing.T) {
+	if _, err := NewResolver([]string{"10.0.0.0/33"}); err == nil {
+		t.Error("expected invalid CIDR to be rejected")
+	}
+	if _, err := NewResolver([]string{"proxy.local"}); err == nil {
+		t.Error("expected host name to be rejected")
+	}
+}
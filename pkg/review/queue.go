@@ -0,0 +1,189 @@
+package review
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/reportportal/landing-aggregator/pkg/audit"
+	"github.com/reportportal/landing-aggregator/pkg/email"
+)
+
+const (
+	typeItem     = "item"
+	typeDecision = "decision"
+)
+
+// Statuses of the queued items
+const (
+	StatusPending  = "pending"
+	StatusApproved = "approved"
+	StatusRejected = "rejected"
+)
+
+// ErrNotFound is returned when item is missing or already resolved
+var ErrNotFound = errors.New("review item not found")
+
+// Item is a request deferred for manual review
+type Item struct {
+	Type      string    `json:"type"`
+	ID        string    `json:"id"`
+	Route     string    `json:"route"`
+	ListID    string    `json:"list_id,omitempty"`
+	Body      string    `json:"body"`
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"user_agent"`
+	Received  time.Time `json:"received"`
+	Status    string    `json:"status"`
+}
+
+// decision resolves previously queued item
+type decision struct {
+	Type      string    `json:"type"`
+	ID        string    `json:"id"`
+	Status    string    `json:"status"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Queue keeps deferred requests and decisions on them in an append-only audit log,
+// so that nothing is lost on restart
+type Queue struct {
+	log *audit.Log
+	mu  sync.Mutex
+}
+
+// NewQueue creates review queue on top of provided audit log
+func NewQueue(log *audit.Log) *Queue {
+	return &Queue{log: log}
+}
+
+// Add appends item to the queue. ID and receive time are populated if missing
+func (q *Queue) Add(item *Item) error {
+	item.Type = typeItem
+	item.Status = StatusPending
+	if item.ID == "" {
+		item.ID = newID()
+	}
+	if item.Received.IsZero() {
+		item.Received = time.Now().UTC()
+	}
+	return q.log.Append(item)
+}
+
+// Pending returns items waiting for decision in order they were queued
+func (q *Queue) Pending() ([]*Item, error) {
+	items := []*Item{}
+	resolved := map[string]bool{}
+	err := q.log.Scan(func(raw json.RawMessage) error {
+		var rec struct {
+			Type string `json:"type"`
+			ID   string `json:"id"`
+		}
+		if err := json.Unmarshal(raw, &rec); err != nil {
+			return nil
+		}
+		switch rec.Type {
+		case typeItem:
+			var item Item
+			if err := json.Unmarshal(raw, &item); err == nil {
+				items = append(items, &item)
+			}
+		case typeDecision:
+			resolved[rec.ID] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pending := items[:0]
+	for _, item := range items {
+		if !resolved[item.ID] {
+			pending = append(pending, item)
+		}
+	}
+	return pending, nil
+}
+
+// Get returns pending item by its ID
+func (q *Queue) Get(id string) (*Item, error) {
+	items, err := q.Pending()
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		if item.ID == id {
+			return item, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// Resolve records decision on the pending item
+func (q *Queue) Resolve(id, status string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, err := q.Get(id); err != nil {
+		return err
+	}
+	return q.log.Append(&decision{
+		Type:      typeDecision,
+		ID:        id,
+		Status:    status,
+		Timestamp: time.Now().UTC(),
+	})
+}
+
+// Erase removes items of the subscriptions of provided email address regardless of their status.
+// Decisions on the removed items are dropped as well
+func (q *Queue) Erase(address string) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	address = email.Normalize(address)
+	erased := map[string]bool{}
+	_, err := q.log.Rewrite(func(raw json.RawMessage) bool {
+		var item Item
+		if err := json.Unmarshal(raw, &item); err != nil {
+			return true
+		}
+		switch item.Type {
+		case typeItem:
+			if itemAddress := item.email(); itemAddress != "" && strings.EqualFold(email.Normalize(itemAddress), address) {
+				erased[item.ID] = true
+				return false
+			}
+		case typeDecision:
+			// decisions are appended after their items
+			return !erased[item.ID]
+		}
+		return true
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(erased), nil
+}
+
+// email returns address of the deferred subscription body
+func (item *Item) email() string {
+	var body struct {
+		EmailAddress string `json:"email_address"`
+	}
+	if err := json.Unmarshal([]byte(item.Body), &body); err != nil {
+		return ""
+	}
+	return strings.TrimSpace(body.EmailAddress)
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
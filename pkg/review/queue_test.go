@@ -0,0 +1,47 @@
+package review
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/reportportal/landing-aggregator/pkg/audit"
+)
+
+func TestErase(t *testing.T) {
+	log, err := audit.Open(filepath.Join(t.TempDir(), "reviews.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer log.Close()
+	queue := NewQueue(log)
+
+	john := &Item{Route: "subscriptions", Body: `{"email_address":"John@example.com"}`}
+	jane := &Item{Route: "subscriptions", Body: `{"email_address":"jane@example.com"}`}
+	resolved := &Item{Route: "mailchimp", ListID: "a", Body: `{"email_address":"john@example.com"}`}
+	for _, item := range []*Item{john, jane, resolved} {
+		if err = queue.Add(item); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = queue.Resolve(resolved.ID, StatusRejected); err != nil {
+		t.Fatal(err)
+	}
+
+	count, err := queue.Erase(" john@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("expected 2 erased items, got %d", count)
+	}
+	pending, err := queue.Pending()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 || pending[0].ID != jane.ID {
+		t.Errorf("unexpected pending items %+v", pending)
+	}
+	if _, err = queue.Get(john.ID); err != ErrNotFound {
+		t.Errorf("expected erased item to be missing, got %v", err)
+	}
+}
@@ -0,0 +1,306 @@
+package snapshot
+
+import (
+	"bytes"
+	"container/list"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/reportportal/landing-aggregator/pkg/compress"
+)
+
+const (
+	defaultCacheSize = 64
+	pathSeparator    = "."
+)
+
+// Section is a named part of the aggregated response. Leaf sections render a single source,
+// parent sections render an object of their nested sections
+type Section struct {
+	Name string
+	// Source returns current snapshot of the leaf section
+	Source func() *Snapshot
+	// Render serializes the leaf snapshot limited to count items. Pre-serialized snapshot JSON is used if not set
+	Render func(s *Snapshot, count int) ([]byte, error)
+	// DefaultCount is passed to Render unless count is requested. MaxCount limits requested count,
+	// sections without MaxCount do not accept count
+	DefaultCount int
+	MaxCount     int
+	// Sections are nested sections of the parent section
+	Sections []Section
+}
+
+// Query selects sections of the response. Sections are addressed by dot-separated paths, e.g. github.stars
+type Query struct {
+	// Include lists sections to render, all sections are rendered if empty
+	Include []string
+	// Exclude lists sections to skip
+	Exclude []string
+	// Counts holds requested count of items per section path
+	Counts map[string]int
+}
+
+// key returns canonical representation of the query
+func (q *Query) key() string {
+	include := append([]string{}, q.Include...)
+	exclude := append([]string{}, q.Exclude...)
+	sort.Strings(include)
+	sort.Strings(exclude)
+	counts := make([]string, 0, len(q.Counts))
+	for path, count := range q.Counts {
+		counts = append(counts, path+"="+strconv.Itoa(count))
+	}
+	sort.Strings(counts)
+	return strings.Join(include, ",") + ";" + strings.Join(exclude, ",") + ";" + strings.Join(counts, ",")
+}
+
+// Rendered is an assembled aggregate response
+type Rendered struct {
+	// Snapshots the response is assembled from
+	Snapshots []*Snapshot
+	// Body is JSON response with its compressed variants
+	Body *compress.Variants
+}
+
+// Aggregate assembles response of several sections from their snapshots.
+// Responses and their compressed variants are cached per query and rebuilt only when version of any selected section changes
+type Aggregate struct {
+	sections []Section
+	paths    map[string]*Section
+
+	mu    sync.Mutex
+	size  int
+	lru   *list.List
+	cache map[string]*list.Element
+}
+
+type cacheEntry struct {
+	query    string
+	versions string
+	body     *compress.Variants
+}
+
+// NewAggregate creates aggregate of provided sections
+func NewAggregate(sections ...Section) *Aggregate {
+	a := &Aggregate{
+		sections: sections,
+		paths:    map[string]*Section{},
+		size:     defaultCacheSize,
+		lru:      list.New(),
+		cache:    map[string]*list.Element{},
+	}
+	var index func(prefix string, sections []Section)
+	index = func(prefix string, sections []Section) {
+		for i := range sections {
+			path := prefix + sections[i].Name
+			a.paths[path] = &sections[i]
+			index(path+pathSeparator, sections[i].Sections)
+		}
+	}
+	index("", a.sections)
+	return a
+}
+
+// ParseQuery reads include and exclude parameters (comma-separated section paths)
+// and "<path>.count" parameters. Unknown sections and counts out of range are rejected
+func (a *Aggregate) ParseQuery(values url.Values) (*Query, error) {
+	q := &Query{Counts: map[string]int{}}
+	for _, param := range []string{"include", "exclude"} {
+		for _, value := range values[param] {
+			for _, path := range strings.Split(value, ",") {
+				path = strings.TrimSpace(path)
+				if path == "" {
+					continue
+				}
+				if _, ok := a.paths[path]; !ok {
+					return nil, fmt.Errorf("unknown section %q", path)
+				}
+				if param == "include" {
+					q.Include = append(q.Include, path)
+				} else {
+					q.Exclude = append(q.Exclude, path)
+				}
+			}
+		}
+	}
+
+	for param := range values {
+		path, ok := strings.CutSuffix(param, pathSeparator+"count")
+		if !ok {
+			continue
+		}
+		section, ok := a.paths[path]
+		if !ok || section.MaxCount == 0 {
+			return nil, fmt.Errorf("section %q does not accept count", path)
+		}
+		count, err := strconv.Atoi(values.Get(param))
+		if err != nil || count < 0 {
+			return nil, fmt.Errorf("invalid %s value", param)
+		}
+		if count > section.MaxCount {
+			return nil, fmt.Errorf("provided count exceed max allower value (%d)", section.MaxCount)
+		}
+		q.Counts[path] = count
+	}
+	return q, nil
+}
+
+// Render returns response assembled from the current snapshots of the sections selected by the query
+func (a *Aggregate) Render(q *Query) (*Rendered, error) {
+	if q == nil {
+		q = &Query{}
+	}
+
+	snapshots := map[*Section]*Snapshot{}
+	var all []*Snapshot
+	var versions []string
+	a.walk("", a.sections, q, func(path string, s *Section) {
+		snapshot := s.Source()
+		snapshots[s] = snapshot
+		all = append(all, snapshot)
+		versions = append(versions, path+"@"+snapshot.Version)
+	})
+	queryKey := q.key()
+	versionsKey := strings.Join(versions, "|")
+
+	if body := a.cached(queryKey, versionsKey); body != nil {
+		return &Rendered{Snapshots: all, Body: body}, nil
+	}
+
+	var buf bytes.Buffer
+	if err := a.renderObject(&buf, "", a.sections, q, snapshots); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	body, err := compress.Encode(buf.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	a.store(queryKey, versionsKey, body)
+	return &Rendered{Snapshots: all, Body: body}, nil
+}
+
+// cached returns body rendered for the query if none of its sections has changed since
+func (a *Aggregate) cached(query, versions string) *compress.Variants {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	el, ok := a.cache[query]
+	if !ok {
+		return nil
+	}
+	entry := el.Value.(*cacheEntry)
+	if entry.versions != versions {
+		return nil
+	}
+	a.lru.MoveToFront(el)
+	return entry.body
+}
+
+// store caches rendered body, evicting the least recently used query if cache is full
+func (a *Aggregate) store(query, versions string, body *compress.Variants) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if el, ok := a.cache[query]; ok {
+		el.Value = &cacheEntry{query: query, versions: versions, body: body}
+		a.lru.MoveToFront(el)
+		return
+	}
+	a.cache[query] = a.lru.PushFront(&cacheEntry{query: query, versions: versions, body: body})
+	if a.lru.Len() > a.size {
+		oldest := a.lru.Back()
+		a.lru.Remove(oldest)
+		delete(a.cache, oldest.Value.(*cacheEntry).query)
+	}
+}
+
+// selected reports whether section is selected by the query. Section is selected if it, its parent
+// or any of its nested sections is included, and neither it nor its parent is excluded
+func selected(path string, q *Query) bool {
+	for _, excluded := range q.Exclude {
+		if path == excluded || strings.HasPrefix(path, excluded+pathSeparator) {
+			return false
+		}
+	}
+	if len(q.Include) == 0 {
+		return true
+	}
+	for _, included := range q.Include {
+		if path == included ||
+			strings.HasPrefix(path, included+pathSeparator) ||
+			strings.HasPrefix(included, path+pathSeparator) {
+			return true
+		}
+	}
+	return false
+}
+
+// walk calls the function for every selected leaf section
+func (a *Aggregate) walk(prefix string, sections []Section, q *Query, f func(path string, s *Section)) {
+	for i := range sections {
+		path := prefix + sections[i].Name
+		if !selected(path, q) {
+			continue
+		}
+		if len(sections[i].Sections) > 0 {
+			a.walk(path+pathSeparator, sections[i].Sections, q, f)
+		} else {
+			f(path, &sections[i])
+		}
+	}
+}
+
+// renderObject writes selected sections as JSON object with keys sorted the same way encoding/json sorts map keys
+func (a *Aggregate) renderObject(buf *bytes.Buffer, prefix string, sections []Section, q *Query, snapshots map[*Section]*Snapshot) error {
+	ordered := make([]*Section, 0, len(sections))
+	for i := range sections {
+		if selected(prefix+sections[i].Name, q) {
+			ordered = append(ordered, &sections[i])
+		}
+	}
+	sort.Slice(ordered, func(i, j int) bool { return ordered[i].Name < ordered[j].Name })
+
+	buf.WriteByte('{')
+	for i, s := range ordered {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		path := prefix + s.Name
+		name, _ := json.Marshal(s.Name)
+		buf.Write(name)
+		buf.WriteByte(':')
+
+		if len(s.Sections) > 0 {
+			if err := a.renderObject(buf, path+pathSeparator, s.Sections, q, snapshots); err != nil {
+				return err
+			}
+			continue
+		}
+
+		snapshot := snapshots[s]
+		value := snapshot.JSON
+		if s.Render != nil {
+			count, ok := q.Counts[path]
+			if !ok {
+				count = s.DefaultCount
+			}
+			var err error
+			if value, err = s.Render(snapshot, count); err != nil {
+				return err
+			}
+		}
+		if value == nil {
+			value = []byte("null")
+		}
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return nil
+}
@@ -0,0 +1,103 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestAggregateRender(t *testing.T) {
+	stars := NewStore(time.Hour, map[string]int{"total": 1})
+	versions := NewStore(time.Hour, map[string]string{"reportportal/service-api": "5.0.0"})
+
+	a := NewAggregate(
+		Section{Name: "latest_versions", Source: versions.Load},
+		Section{Name: "github", Sections: []Section{{Name: "stars", Source: stars.Load}}},
+	)
+
+	expected, _ := json.Marshal(map[string]interface{}{
+		"github":          map[string]interface{}{"stars": stars.Load().Data},
+		"latest_versions": versions.Load().Data,
+	})
+
+	first, err := a.Render(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, body := first.Body.Negotiate("")
+	if string(body) != string(expected)+"\n" {
+		t.Errorf("expected %s, got %s", expected, body)
+	}
+
+	second, _ := a.Render(nil)
+	if second.Body != first.Body {
+		t.Error("unchanged sections must reuse rendered body")
+	}
+
+	stars.Set(map[string]int{"total": 2})
+	third, _ := a.Render(nil)
+	if third.Body == first.Body {
+		t.Error("changed section must re-render body")
+	}
+
+	rs := httptest.NewRecorder()
+	rq := httptest.NewRequest("GET", "/", nil)
+	rq.Header.Set("Accept-Encoding", "gzip")
+	if err = third.Body.Write(rs, rq, 200, "application/json"); err != nil {
+		t.Fatal(err)
+	}
+	if rs.Header().Get("Content-Encoding") != "" {
+		t.Error("tiny body must not be compressed when compression does not shrink it")
+	}
+}
+
+func TestAggregateQuery(t *testing.T) {
+	videos := NewStore(time.Hour, []int{1, 2, 3, 4, 5})
+	stars := NewStore(time.Hour, 42)
+	issues := NewStore(time.Hour, 7)
+
+	a := NewAggregate(
+		Section{Name: "youtube", Source: videos.Load, DefaultCount: 3, MaxCount: 5,
+			Render: func(s *Snapshot, count int) ([]byte, error) {
+				items := s.Data.([]int)
+				return json.Marshal(items[:min(count, len(items))])
+			}},
+		Section{Name: "github", Sections: []Section{
+			{Name: "stars", Source: stars.Load},
+			{Name: "issue_stats", Source: issues.Load},
+		}},
+	)
+
+	cases := []struct {
+		query    string
+		expected string
+	}{
+		{"", `{"github":{"issue_stats":7,"stars":42},"youtube":[1,2,3]}`},
+		{"include=github.stars,youtube&youtube.count=5", `{"github":{"stars":42},"youtube":[1,2,3,4,5]}`},
+		{"exclude=github", `{"youtube":[1,2,3]}`},
+		{"include=github&exclude=github.issue_stats", `{"github":{"stars":42}}`},
+	}
+	for _, c := range cases {
+		values, _ := url.ParseQuery(c.query)
+		q, err := a.ParseQuery(values)
+		if err != nil {
+			t.Fatalf("%s: %v", c.query, err)
+		}
+		rendered, err := a.Render(q)
+		if err != nil {
+			t.Fatalf("%s: %v", c.query, err)
+		}
+		if _, body := rendered.Body.Negotiate(""); string(body) != c.expected+"\n" {
+			t.Errorf("%s: expected %s, got %s", c.query, c.expected, body)
+		}
+	}
+
+	for _, invalid := range []string{"include=twitter", "youtube.count=6", "github.stars.count=1", "youtube.count=x"} {
+		values, _ := url.ParseQuery(invalid)
+		if _, err := a.ParseQuery(values); err == nil {
+			t.Errorf("%s: expected error", invalid)
+		}
+	}
+}
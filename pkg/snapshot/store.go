@@ -0,0 +1,128 @@
+package snapshot
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Snapshot is an immutable state of a data source
+type Snapshot struct {
+	Data interface{}
+	// JSON is the data serialized when snapshot was taken
+	JSON []byte
+	// Version is a hash of JSON representation of the data, identical on every instance holding the same data
+	Version string
+	// Modified is the time data was last changed
+	Modified time.Time
+	// Refreshed is the time source was last refreshed, even if data has not changed
+	Refreshed time.Time
+	// Period is the refresh period of the source
+	Period time.Duration
+}
+
+// Fresh returns how long the snapshot stays unchanged, until the next scheduled refresh
+func (s *Snapshot) Fresh() time.Duration {
+	if s.Static() {
+		return 0
+	}
+	if s.Refreshed.IsZero() {
+		return 0
+	}
+	if left := s.Period - time.Since(s.Refreshed); left > 0 {
+		return left
+	}
+	return 0
+}
+
+// Static reports whether snapshot data never changes
+func (s *Snapshot) Static() bool {
+	return s.Period == 0
+}
+
+// Store holds the latest snapshot of a data source refreshed every period
+type Store struct {
+	period  time.Duration
+	mu      sync.Mutex
+	current atomic.Value
+}
+
+// NewStore creates store with initial data. Initial snapshot is not considered refreshed
+func NewStore(period time.Duration, initial interface{}) *Store {
+	s := &Store{period: period}
+	s.current.Store(s.build(initial, time.Time{}))
+	return s
+}
+
+// Load returns the latest snapshot
+func (s *Store) Load() *Snapshot {
+	return s.current.Load().(*Snapshot)
+}
+
+// Set replaces data of the store. Version and modification time change only if data differs from the current one
+func (s *Store) Set(data interface{}) {
+	s.Update(func(interface{}) interface{} {
+		return data
+	})
+}
+
+// Update replaces data with the result of the function applied to the current data
+func (s *Store) Update(f func(current interface{}) interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	current := s.Load()
+	next := s.build(f(current.Data), now)
+	if next.Version == current.Version {
+		next.Modified = current.Modified
+	}
+	s.current.Store(next)
+}
+
+// SetPeriod changes refresh period of the source, applied to the snapshots taken from now on
+func (s *Store) SetPeriod(period time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.period = period
+}
+
+// Touch marks the store refreshed without changing the data
+func (s *Store) Touch() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	next := *s.Load()
+	next.Refreshed = time.Now()
+	next.Period = s.period
+	s.current.Store(&next)
+}
+
+func (s *Store) build(data interface{}, refreshed time.Time) *Snapshot {
+	now := time.Now()
+	snapshot := &Snapshot{Data: data, Modified: now, Refreshed: refreshed, Period: s.period}
+	if b, err := json.Marshal(data); err == nil {
+		snapshot.JSON = b
+		snapshot.Version = version(b)
+	}
+	return snapshot
+}
+
+// Static creates snapshot of the data which never changes
+func Static(data interface{}) *Snapshot {
+	snapshot := &Snapshot{Data: data, Modified: time.Now()}
+	if b, err := json.Marshal(data); err == nil {
+		snapshot.JSON = b
+		snapshot.Version = version(b)
+	}
+	return snapshot
+}
+
+func version(b []byte) string {
+	hash := sha256.Sum256(b)
+	return hex.EncodeToString(hash[:12])
+}